@@ -2,10 +2,13 @@ package cache
 
 import (
 	"context"
+	"sync"
 	"time"
 )
 
+// InMemoryCache is a Cache kept in a map, safe for concurrent use
 type InMemoryCache struct {
+	mu    sync.Mutex
 	cache map[string]cacheValue
 }
 
@@ -21,11 +24,17 @@ func NewInMemoryCache() *InMemoryCache {
 }
 
 func (i *InMemoryCache) InvalidateValue(_ context.Context, key string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	delete(i.cache, key)
 	return nil
 }
 
 func (i *InMemoryCache) StoreValue(_ context.Context, key string, value []byte) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	i.cache[key] = cacheValue{
 		value:  value,
 		expiry: nil,
@@ -37,6 +46,9 @@ func (i *InMemoryCache) StoreValue(_ context.Context, key string, value []byte)
 func (i *InMemoryCache) StoreValueWithExpiry(_ context.Context, key string, value []byte, expiresIn time.Duration) error {
 	expiry := time.Now().Add(expiresIn)
 
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	i.cache[key] = cacheValue{
 		value:  value,
 		expiry: &expiry,
@@ -46,6 +58,9 @@ func (i *InMemoryCache) StoreValueWithExpiry(_ context.Context, key string, valu
 }
 
 func (i *InMemoryCache) RetrieveValue(_ context.Context, key string) ([]byte, error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
 	if value, ok := i.cache[key]; ok {
 		if value.expiry != nil {
 			if time.Now().After(*value.expiry) {
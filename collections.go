@@ -0,0 +1,152 @@
+package fcthelp
+
+import "sort"
+
+// Ordered is any type that supports the < operator
+type Ordered interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 |
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr |
+		~float32 | ~float64 |
+		~string
+}
+
+// Pair is a single item produced by Zip
+type Pair[A any, B any] struct {
+	First  A
+	Second B
+}
+
+// GroupBy groups the items of a slice by the key returned for each item, items keep their original order
+func GroupBy[T any, K comparable](slice []T, key func(item T) K) map[K][]T {
+	groups := make(map[K][]T)
+
+	for _, item := range slice {
+		k := key(item)
+		groups[k] = append(groups[k], item)
+	}
+
+	return groups
+}
+
+// KeyBy creates a map of items by the key returned for each item, if keys collide the last item wins
+func KeyBy[T any, K comparable](slice []T, key func(item T) K) map[K]T {
+	keyed := make(map[K]T, len(slice))
+
+	for _, item := range slice {
+		keyed[key(item)] = item
+	}
+
+	return keyed
+}
+
+// Chunk splits a slice into slices of at most size items, size must be greater than 0
+// the chunks share the backing array of slice, but are capped so appending to one never overwrites the next
+func Chunk[T any](slice []T, size int) [][]T {
+	if size <= 0 {
+		panic("chunk size must be greater than 0")
+	}
+
+	var chunks [][]T
+
+	for start := 0; start < len(slice); start += size {
+		end := start + size
+		if end > len(slice) {
+			end = len(slice)
+		}
+
+		chunks = append(chunks, slice[start:end:end])
+	}
+
+	return chunks
+}
+
+// Partition splits a slice in two, items for which the predicate returns true are in matched, the rest are in unmatched
+func Partition[T any](slice []T, predicate func(i T, idx int) bool) (matched []T, unmatched []T) {
+	for idx, i := range slice {
+		if predicate(i, idx) {
+			matched = append(matched, i)
+		} else {
+			unmatched = append(unmatched, i)
+		}
+	}
+
+	return
+}
+
+// UniqueBy removes items with duplicate keys, the first item with each key is kept
+func UniqueBy[T any, K comparable](slice []T, key func(item T) K) []T {
+	seen := make(Set[K])
+
+	var unique []T
+
+	for _, item := range slice {
+		k := key(item)
+
+		if !seen.Contains(k) {
+			seen.Add(k)
+			unique = append(unique, item)
+		}
+	}
+
+	return unique
+}
+
+// Flatten joins a slice of slices into a single slice
+func Flatten[T any](slices [][]T) []T {
+	var flattened []T
+
+	for _, s := range slices {
+		flattened = append(flattened, s...)
+	}
+
+	return flattened
+}
+
+// Zip pairs up the items of two slices, the result is as long as the shorter slice
+func Zip[A any, B any](a []A, b []B) []Pair[A, B] {
+	length := len(a)
+	if len(b) < length {
+		length = len(b)
+	}
+
+	zipped := make([]Pair[A, B], length)
+
+	for i := 0; i < length; i++ {
+		zipped[i] = Pair[A, B]{First: a[i], Second: b[i]}
+	}
+
+	return zipped
+}
+
+// Reduce combines every item of a slice into a single value
+func Reduce[T any, A any](slice []T, initial A, reducer func(acc A, item T, idx int) A) A {
+	acc := initial
+
+	for idx, item := range slice {
+		acc = reducer(acc, item, idx)
+	}
+
+	return acc
+}
+
+// SortedMapKeys is MapKeys, with the keys in ascending order
+func SortedMapKeys[K Ordered, V any](m map[K]V) []K {
+	keys := MapKeys(m)
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
+
+// SortedMapToSlice is MapToSlice, with the pairs in ascending key order
+func SortedMapToSlice[K Ordered, V any](m map[K]V) []KV[K, V] {
+	kv := MapToSlice(m)
+
+	sort.Slice(kv, func(i, j int) bool {
+		return kv[i].K < kv[j].K
+	})
+
+	return kv
+}
@@ -0,0 +1,110 @@
+package fcthelp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestGroupBy(t *testing.T) {
+	t.Parallel()
+
+	groups := GroupBy([]string{"apple", "bean", "avocado", "beet", "corn"}, func(item string) byte { return item[0] })
+
+	assert.Equal(t, map[byte][]string{
+		'a': {"apple", "avocado"},
+		'b': {"bean", "beet"},
+		'c': {"corn"},
+	}, groups)
+
+	assert.Empty(t, GroupBy(nil, func(item string) string { return item }))
+}
+
+func TestKeyBy(t *testing.T) {
+	t.Parallel()
+
+	keyed := KeyBy([]string{"apple", "bean", "avocado"}, func(item string) byte { return item[0] })
+	assert.Equal(t, map[byte]string{'a': "avocado", 'b': "bean"}, keyed)
+
+	assert.Empty(t, KeyBy(nil, func(item string) string { return item }))
+}
+
+func TestChunk(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, [][]int{{1, 2}, {3, 4}, {5}}, Chunk([]int{1, 2, 3, 4, 5}, 2))
+	assert.Equal(t, [][]int{{1, 2}}, Chunk([]int{1, 2}, 5))
+	assert.Empty(t, Chunk([]int{}, 2))
+	assert.Empty(t, Chunk[int](nil, 2))
+
+	assert.Panics(t, func() { Chunk([]int{1}, 0) })
+
+	t.Run("appending to a chunk leaves the rest alone", func(t *testing.T) {
+		slice := []int{1, 2, 3, 4, 5}
+		chunks := Chunk(slice, 2)
+
+		chunks[0] = append(chunks[0], 9)
+
+		assert.Equal(t, []int{1, 2, 9}, chunks[0])
+		assert.Equal(t, []int{3, 4}, chunks[1])
+		assert.Equal(t, []int{1, 2, 3, 4, 5}, slice)
+	})
+}
+
+func TestPartition(t *testing.T) {
+	t.Parallel()
+
+	even, odd := Partition([]int{1, 2, 3, 4, 5}, func(i int, idx int) bool { return i%2 == 0 })
+	assert.Equal(t, []int{2, 4}, even)
+	assert.Equal(t, []int{1, 3, 5}, odd)
+
+	matched, unmatched := Partition[int](nil, func(i int, idx int) bool { return true })
+	assert.Empty(t, matched)
+	assert.Empty(t, unmatched)
+}
+
+func TestUniqueBy(t *testing.T) {
+	t.Parallel()
+
+	unique := UniqueBy([]string{"Apple", "apple", "Bean", "APPLE", "bean"}, strings.ToLower)
+	assert.Equal(t, []string{"Apple", "Bean"}, unique)
+
+	assert.Empty(t, UniqueBy(nil, strings.ToLower))
+}
+
+func TestFlatten(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []int{1, 2, 3, 4}, Flatten([][]int{{1, 2}, nil, {}, {3, 4}}))
+	assert.Empty(t, Flatten[int](nil))
+}
+
+func TestZip(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []Pair[int, string]{{First: 1, Second: "a"}, {First: 2, Second: "b"}}, Zip([]int{1, 2, 3}, []string{"a", "b"}))
+	assert.Empty(t, Zip([]int{1}, []string(nil)))
+	assert.Empty(t, Zip[int, string](nil, nil))
+}
+
+func TestReduce(t *testing.T) {
+	t.Parallel()
+
+	sum := Reduce([]int{1, 2, 3}, 10, func(acc int, item int, idx int) int { return acc + item })
+	assert.Equal(t, 16, sum)
+
+	joined := Reduce([]string{"a", "b"}, "", func(acc string, item string, idx int) string { return acc + item })
+	assert.Equal(t, "ab", joined)
+
+	assert.Equal(t, 10, Reduce(nil, 10, func(acc int, item int, idx int) int { return acc + item }))
+}
+
+func TestSortedMapKeys(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{"a", "b", "c"}, SortedMapKeys(map[string]int{"c": 3, "a": 1, "b": 2}))
+	assert.Empty(t, SortedMapKeys[string, int](nil))
+
+	assert.Equal(t, []KV[int, string]{{K: 1, V: "a"}, {K: 2, V: "b"}}, SortedMapToSlice(map[int]string{2: "b", 1: "a"}))
+	assert.Empty(t, SortedMapToSlice[int, string](nil))
+}
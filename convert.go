@@ -0,0 +1,551 @@
+package fcthelp
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type StrictConvertOpts struct {
+	// AllowDropped allows source fields that don't exist on the target, they are still listed in the report
+	AllowDropped bool
+
+	// RequireAll returns an error if any target field was not set by the source
+	RequireAll bool
+}
+
+// ConvertReport describes how the fields of the source were mapped onto the target by JSONConvertStrict
+// paths are dot separated json names, items of slices are written as name[]
+type ConvertReport struct {
+	// DroppedFields are fields of the source that don't exist on the target
+	DroppedFields []string
+
+	// ZeroFields are fields of the target that were missing or null in the source, and were left as their zero value
+	ZeroFields []string
+}
+
+// Clean is true when every source field was used, and every target field was set
+func (r *ConvertReport) Clean() bool {
+	return len(r.DroppedFields) == 0 && len(r.ZeroFields) == 0
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// JSONConvertStrict is JSONConvert, but source fields that don't exist on the target are an error
+// the report lists dropped source fields and target fields that were left zero, it is returned even when err is not nil
+// types with their own json encoding (maybe.Maybe, null.String, time.Time etc.) are treated as single values
+func JSONConvertStrict[R any](input any, opts ...*StrictConvertOpts) (R, *ConvertReport, error) {
+	var output R
+
+	options := &StrictConvertOpts{}
+	if len(opts) > 0 && opts[0] != nil {
+		options = opts[0]
+	}
+
+	jsb, err := json.Marshal(input)
+	if err != nil {
+		return output, nil, ferr.Wrap(err)
+	}
+
+	var source any
+
+	decoder := json.NewDecoder(bytes.NewReader(jsb))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&source); err != nil {
+		return output, nil, ferr.Wrap(err)
+	}
+
+	dropped, zero := NewSet[string](), NewSet[string]()
+	collectConvertDiagnostics(reflect.TypeOf(&output).Elem(), source, "", dropped, zero)
+
+	report := &ConvertReport{
+		DroppedFields: SortedMapKeys(dropped),
+		ZeroFields:    SortedMapKeys(zero),
+	}
+
+	if len(report.DroppedFields) > 0 && !options.AllowDropped {
+		return output, report, ferr.Wrap(fmt.Errorf("source fields do not exist on %T: %s", output, strings.Join(report.DroppedFields, ", ")))
+	}
+
+	if len(report.ZeroFields) > 0 && options.RequireAll {
+		return output, report, ferr.Wrap(fmt.Errorf("fields of %T were not set: %s", output, strings.Join(report.ZeroFields, ", ")))
+	}
+
+	if err := json.Unmarshal(jsb, &output); err != nil {
+		return output, report, ferr.Wrap(err)
+	}
+
+	return output, report, nil
+}
+
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+//revive:disable:cyclomatic Mirrors the way encoding/json walks a value
+func collectConvertDiagnostics(t reflect.Type, source any, path string, dropped Set[string], zero Set[string]) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if isJSONLeaf(t) {
+		return
+	}
+
+	switch s := source.(type) {
+	case map[string]any:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+
+		fields := jsonFields(t)
+		matched := NewSet[string]()
+
+		for key, val := range s {
+			field, ok := matchJSONField(fields, key)
+			if !ok {
+				dropped.Add(joinConvertPath(path, key))
+				continue
+			}
+
+			matched.Add(field.name)
+
+			if val == nil {
+				zero.Add(joinConvertPath(path, field.name))
+				continue
+			}
+
+			collectConvertDiagnostics(field.typ, val, joinConvertPath(path, field.name), dropped, zero)
+		}
+
+		for _, field := range fields {
+			if !matched.Contains(field.name) {
+				zero.Add(joinConvertPath(path, field.name))
+			}
+		}
+	case []any:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return
+		}
+
+		for _, item := range s {
+			collectConvertDiagnostics(t.Elem(), item, path+"[]", dropped, zero)
+		}
+	}
+}
+
+func isJSONLeaf(t reflect.Type) bool {
+	ptr := reflect.PtrTo(t)
+	return ptr.Implements(jsonUnmarshalerType) || ptr.Implements(textUnmarshalerType)
+}
+
+// jsonFields lists the fields encoding/json would decode into, including the fields of embedded structs
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+
+			if embedded.Kind() == reflect.Struct {
+				fields = append(fields, jsonFields(embedded)...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields = append(fields, jsonField{name: name, typ: field.Type})
+	}
+
+	return fields
+}
+
+// matchJSONField finds the field for a key, preferring an exact match, and falling back to a case-insensitive one like encoding/json
+func matchJSONField(fields []jsonField, key string) (jsonField, bool) {
+	for _, field := range fields {
+		if field.name == key {
+			return field, true
+		}
+	}
+
+	for _, field := range fields {
+		if strings.EqualFold(field.name, key) {
+			return field, true
+		}
+	}
+
+	return jsonField{}, false
+}
+
+func joinConvertPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+
+	return path + "." + name
+}
+
+// MapStruct copies the fields of src onto a new R, matching fields by name (case-insensitive)
+// a `map:"name"` tag on either side changes the name a field is matched by, and `map:"-"` skips the field
+//
+// fields are copied when the types are assignable, numeric or string types are converted,
+// pointers, maybe.Maybe, maybe.Patch and null.* types are converted to and from their inner values,
+// and nested structs, slices and maps are mapped recursively. Target fields without a matching source field are left zero
+func MapStruct[R any](src any) (R, error) {
+	var output R
+
+	err := MapStructInto(&output, src)
+	if err != nil {
+		return output, err
+	}
+
+	return output, nil
+}
+
+// MapStructInto is MapStruct, writing into dst, which must be a pointer to a struct
+func MapStructInto(dst any, src any) error {
+	dstValue := reflect.ValueOf(dst)
+	if dstValue.Kind() != reflect.Ptr || dstValue.IsNil() {
+		return ferr.Wrap(fmt.Errorf("map target must be a non nil pointer, got %T", dst))
+	}
+
+	srcValue := reflect.ValueOf(src)
+	if !srcValue.IsValid() {
+		return nil
+	}
+
+	for srcValue.Kind() == reflect.Ptr {
+		if srcValue.IsNil() {
+			return nil
+		}
+
+		srcValue = srcValue.Elem()
+	}
+
+	convert, err := mapConverter(dstValue.Elem().Type(), srcValue.Type())
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	if err := convert(dstValue.Elem(), srcValue); err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+// mapConvertFunc writes src into dst, dst is always settable
+type mapConvertFunc func(dst reflect.Value, src reflect.Value) error
+
+type mapPlanKey struct {
+	dst reflect.Type
+	src reflect.Type
+}
+
+type mapFieldStep struct {
+	name    string
+	dst     []int
+	src     []int
+	convert mapConvertFunc
+}
+
+// mapPlans caches the field steps for every pair of struct types, so reflection on tags only happens once
+var mapPlans sync.Map
+
+//revive:disable:cyclomatic Each supported conversion is a separate case
+func mapConverter(dst reflect.Type, src reflect.Type) (mapConvertFunc, error) {
+	if src.AssignableTo(dst) {
+		return func(d reflect.Value, s reflect.Value) error {
+			d.Set(s)
+			return nil
+		}, nil
+	}
+
+	if unwrap, ok := optionalUnwrapMethod(src); ok {
+		inner, err := mapConverter(dst, unwrap.Type.Out(0))
+		if err != nil {
+			return nil, err
+		}
+
+		return func(d reflect.Value, s reflect.Value) error {
+			return inner(d, s.Method(unwrap.Index).Call(nil)[0])
+		}, nil
+	}
+
+	if setter, ok := optionalSetMethod(dst); ok {
+		inner, err := mapConverter(setter.Type.In(1), src)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(d reflect.Value, s reflect.Value) error {
+			if s.Kind() == reflect.Ptr && s.IsNil() {
+				d.Set(reflect.Zero(d.Type()))
+				return nil
+			}
+
+			val := reflect.New(setter.Type.In(1)).Elem()
+			if err := inner(val, s); err != nil {
+				return err
+			}
+
+			d.Addr().Method(setter.Index).Call([]reflect.Value{val})
+
+			return nil
+		}, nil
+	}
+
+	if src.Kind() == reflect.Ptr {
+		inner, err := mapConverter(dst, src.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return func(d reflect.Value, s reflect.Value) error {
+			if s.IsNil() {
+				d.Set(reflect.Zero(d.Type()))
+				return nil
+			}
+
+			return inner(d, s.Elem())
+		}, nil
+	}
+
+	if dst.Kind() == reflect.Ptr {
+		inner, err := mapConverter(dst.Elem(), src)
+		if err != nil {
+			return nil, err
+		}
+
+		return func(d reflect.Value, s reflect.Value) error {
+			ptr := reflect.New(dst.Elem())
+			if err := inner(ptr.Elem(), s); err != nil {
+				return err
+			}
+
+			d.Set(ptr)
+
+			return nil
+		}, nil
+	}
+
+	switch {
+	case dst.Kind() == reflect.Struct && src.Kind() == reflect.Struct:
+		// plans are looked up when converting, so that self referencing types don't recurse forever
+		return func(d reflect.Value, s reflect.Value) error {
+			steps, err := mapPlan(dst, src)
+			if err != nil {
+				return err
+			}
+
+			for _, step := range steps {
+				if err := step.convert(d.FieldByIndex(step.dst), s.FieldByIndex(step.src)); err != nil {
+					return fmt.Errorf("%s: %w", step.name, err)
+				}
+			}
+
+			return nil
+		}, nil
+	case dst.Kind() == reflect.Slice && (src.Kind() == reflect.Slice || src.Kind() == reflect.Array):
+		inner, err := mapConverter(dst.Elem(), src.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return func(d reflect.Value, s reflect.Value) error {
+			if s.Kind() == reflect.Slice && s.IsNil() {
+				d.Set(reflect.Zero(d.Type()))
+				return nil
+			}
+
+			out := reflect.MakeSlice(dst, s.Len(), s.Len())
+			for i := 0; i < s.Len(); i++ {
+				if err := inner(out.Index(i), s.Index(i)); err != nil {
+					return fmt.Errorf("[%d]: %w", i, err)
+				}
+			}
+
+			d.Set(out)
+
+			return nil
+		}, nil
+	case dst.Kind() == reflect.Map && src.Kind() == reflect.Map && src.Key().AssignableTo(dst.Key()):
+		inner, err := mapConverter(dst.Elem(), src.Elem())
+		if err != nil {
+			return nil, err
+		}
+
+		return func(d reflect.Value, s reflect.Value) error {
+			if s.IsNil() {
+				d.Set(reflect.Zero(d.Type()))
+				return nil
+			}
+
+			out := reflect.MakeMapWithSize(dst, s.Len())
+			iter := s.MapRange()
+
+			for iter.Next() {
+				val := reflect.New(dst.Elem()).Elem()
+				if err := inner(val, iter.Value()); err != nil {
+					return fmt.Errorf("[%v]: %w", iter.Key(), err)
+				}
+
+				out.SetMapIndex(iter.Key(), val)
+			}
+
+			d.Set(out)
+
+			return nil
+		}, nil
+	case isNumericKind(dst.Kind()) && isNumericKind(src.Kind()),
+		dst.Kind() == reflect.String && src.Kind() == reflect.String,
+		dst.Kind() == reflect.Bool && src.Kind() == reflect.Bool:
+		return func(d reflect.Value, s reflect.Value) error {
+			d.Set(s.Convert(dst))
+			return nil
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cannot map %s to %s", src, dst)
+}
+
+func mapPlan(dst reflect.Type, src reflect.Type) ([]mapFieldStep, error) {
+	key := mapPlanKey{dst: dst, src: src}
+
+	if cached, ok := mapPlans.Load(key); ok {
+		return cached.([]mapFieldStep), nil
+	}
+
+	srcFields := mapFields(src, nil)
+
+	var steps []mapFieldStep
+
+	for name, dstIndex := range mapFields(dst, nil) {
+		srcIndex, ok := srcFields[name]
+		if !ok {
+			continue
+		}
+
+		convert, err := mapConverter(dst.FieldByIndex(dstIndex).Type, src.FieldByIndex(srcIndex).Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		steps = append(steps, mapFieldStep{name: name, dst: dstIndex, src: srcIndex, convert: convert})
+	}
+
+	// keep the order of the steps stable, so errors are reported consistently
+	sort.Slice(steps, func(i, j int) bool {
+		return steps[i].name < steps[j].name
+	})
+
+	mapPlans.Store(key, steps)
+
+	return steps, nil
+}
+
+// mapFields indexes the exported fields of t by the name they are matched by, fields of embedded structs are included
+func mapFields(t reflect.Type, parent []int) map[string][]int {
+	fields := make(map[string][]int)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		index := append(append([]int{}, parent...), i)
+
+		name := field.Tag.Get("map")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for embeddedName, embeddedIndex := range mapFields(field.Type, index) {
+				// fields declared directly on the struct win over embedded ones
+				if _, exists := fields[embeddedName]; !exists {
+					fields[embeddedName] = embeddedIndex
+				}
+			}
+
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fields[strings.ToLower(name)] = index
+	}
+
+	return fields
+}
+
+// optionalUnwrapMethod finds the method that turns an optional wrapper (maybe.Maybe, maybe.Patch, null.*) into a pointer
+func optionalUnwrapMethod(t reflect.Type) (reflect.Method, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.Method{}, false
+	}
+
+	for _, name := range []string{"ToPtr", "Ptr"} {
+		method, ok := t.MethodByName(name)
+		if ok && method.Type.NumIn() == 1 && method.Type.NumOut() >= 1 && method.Type.Out(0).Kind() == reflect.Ptr {
+			return method, true
+		}
+	}
+
+	return reflect.Method{}, false
+}
+
+// optionalSetMethod finds the method that sets the value of an optional wrapper, it is called on a pointer to t
+func optionalSetMethod(t reflect.Type) (reflect.Method, bool) {
+	if t.Kind() != reflect.Struct {
+		return reflect.Method{}, false
+	}
+
+	ptr := reflect.PtrTo(t)
+
+	for _, name := range []string{"Set", "SetValid"} {
+		method, ok := ptr.MethodByName(name)
+		if ok && method.Type.NumIn() == 2 && method.Type.NumOut() == 0 {
+			return method, true
+		}
+	}
+
+	return reflect.Method{}, false
+}
+
+func isNumericKind(k reflect.Kind) bool {
+	return (k >= reflect.Int && k <= reflect.Uint64) || k == reflect.Float32 || k == reflect.Float64
+}
@@ -0,0 +1,245 @@
+package fcthelp
+
+import (
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/maybe"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"testing"
+	"time"
+)
+
+type convertTestBase struct {
+	ID        string
+	CreatedAt time.Time
+}
+
+type convertTestAddressRow struct {
+	Street string
+	Zip    null.String
+}
+
+type convertTestUserRow struct {
+	convertTestBase
+
+	Name      string
+	Nickname  null.String
+	Age       int32
+	Score     *float64
+	Email     string `map:"contact"`
+	Password  string
+	Addresses []convertTestAddressRow
+	Labels    map[string]int64
+	Manager   *convertTestUserRow
+}
+
+type convertTestAddress struct {
+	Street string
+	Zip    maybe.Maybe[string]
+}
+
+type convertTestUser struct {
+	Id        string
+	CreatedAt time.Time
+	NAME      string
+	Nickname  maybe.Maybe[string]
+	Age       int
+	Score     float64
+	Contact   string
+	Password  string `map:"-"`
+	Addresses []convertTestAddress
+	Labels    map[string]float64
+	Manager   *convertTestUser
+	Extra     string
+}
+
+func TestMapStruct(t *testing.T) {
+	t.Parallel()
+
+	score := 9.5
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	row := convertTestUserRow{
+		convertTestBase: convertTestBase{ID: "u1", CreatedAt: created},
+		Name:            "Jim",
+		Nickname:        null.StringFrom("jimbo"),
+		Age:             40,
+		Score:           &score,
+		Email:           "jim@example.com",
+		Password:        "secret",
+		Addresses:       []convertTestAddressRow{{Street: "Main", Zip: null.StringFrom("12345")}, {Street: "Side"}},
+		Labels:          map[string]int64{"a": 1},
+		Manager:         &convertTestUserRow{Name: "Boss"},
+	}
+
+	user, err := MapStruct[convertTestUser](&row)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, "u1", user.Id)
+	assert.Equal(t, created, user.CreatedAt)
+	assert.Equal(t, "Jim", user.NAME)
+	assert.Equal(t, maybe.WithValue("jimbo"), user.Nickname)
+	assert.Equal(t, 40, user.Age)
+	assert.Equal(t, 9.5, user.Score)
+	assert.Equal(t, "jim@example.com", user.Contact)
+	assert.Empty(t, user.Password)
+	assert.Equal(t, []convertTestAddress{{Street: "Main", Zip: maybe.WithValue("12345")}, {Street: "Side"}}, user.Addresses)
+	assert.Equal(t, map[string]float64{"a": 1}, user.Labels)
+	assert.Empty(t, user.Extra)
+
+	if assert.NotNil(t, user.Manager) {
+		assert.Equal(t, "Boss", user.Manager.NAME)
+		assert.Nil(t, user.Manager.Manager)
+	}
+
+	t.Run("back again", func(t *testing.T) {
+		back, err := MapStruct[convertTestUserRow](user)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, row.Name, back.Name)
+		assert.Equal(t, row.Nickname, back.Nickname)
+		assert.Equal(t, row.Email, back.Email)
+		assert.Equal(t, row.Addresses, back.Addresses)
+		assert.Empty(t, back.Password)
+
+		if assert.NotNil(t, back.Score) {
+			assert.Equal(t, 9.5, *back.Score)
+		}
+	})
+
+	t.Run("empty values", func(t *testing.T) {
+		user, err := MapStruct[convertTestUser](convertTestUserRow{})
+		assert.NoError(t, err)
+		assert.False(t, user.Nickname.HasValue())
+		assert.Zero(t, user.Score)
+		assert.Nil(t, user.Addresses)
+		assert.Nil(t, user.Labels)
+		assert.Nil(t, user.Manager)
+
+		back, err := MapStruct[convertTestUserRow](convertTestUser{})
+		assert.NoError(t, err)
+		assert.False(t, back.Nickname.Valid)
+		assert.NotNil(t, back.Score, "non pointer sources always set pointer targets")
+	})
+
+	t.Run("nil source", func(t *testing.T) {
+		user, err := MapStruct[convertTestUser](nil)
+		assert.NoError(t, err)
+		assert.Equal(t, convertTestUser{}, user)
+
+		user, err = MapStruct[convertTestUser]((*convertTestUserRow)(nil))
+		assert.NoError(t, err)
+		assert.Equal(t, convertTestUser{}, user)
+	})
+
+	t.Run("patch fields", func(t *testing.T) {
+		type update struct {
+			Name     maybe.Patch[string]
+			Nickname maybe.Patch[string]
+		}
+
+		type target struct {
+			Name     *string
+			Nickname maybe.Maybe[string]
+		}
+
+		out, err := MapStruct[target](update{Name: maybe.PatchWithValue("Jim"), Nickname: maybe.PatchNull[string]()})
+		assert.NoError(t, err)
+
+		if assert.NotNil(t, out.Name) {
+			assert.Equal(t, "Jim", *out.Name)
+		}
+
+		assert.False(t, out.Nickname.HasValue())
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := MapStruct[struct{ Name int }](struct{ Name string }{Name: "Jim"})
+		assert.Error(t, err)
+
+		_, err = MapStruct[struct{ Labels map[int]string }](struct{ Labels map[string]string }{})
+		assert.Error(t, err)
+
+		var user convertTestUser
+		assert.Error(t, MapStructInto(user, row))
+		assert.Error(t, MapStructInto((*convertTestUser)(nil), row))
+	})
+}
+
+type convertTestStrictSource struct {
+	Name    string                  `json:"name"`
+	Email   string                  `json:"email"`
+	Items   []convertTestStrictItem `json:"items"`
+	Deleted *bool                   `json:"deleted"`
+}
+
+type convertTestStrictItem struct {
+	SKU   string `json:"sku"`
+	Price int    `json:"price"`
+}
+
+type convertTestStrictTarget struct {
+	Name    string                        `json:"name"`
+	Items   []convertTestStrictTargetItem `json:"items"`
+	Deleted maybe.Maybe[bool]             `json:"deleted"`
+	Age     int                           `json:"age"`
+}
+
+type convertTestStrictTargetItem struct {
+	SKU string `json:"SKU"`
+}
+
+func TestJSONConvertStrict(t *testing.T) {
+	t.Parallel()
+
+	source := convertTestStrictSource{
+		Name:  "Jim",
+		Email: "jim@example.com",
+		Items: []convertTestStrictItem{{SKU: "a", Price: 1}},
+	}
+
+	_, report, err := JSONConvertStrict[convertTestStrictTarget](source)
+	assert.Error(t, err)
+
+	if assert.NotNil(t, report) {
+		assert.Equal(t, []string{"email", "items[].price"}, report.DroppedFields)
+		assert.Equal(t, []string{"age", "deleted"}, report.ZeroFields)
+		assert.False(t, report.Clean())
+	}
+
+	out, report, err := JSONConvertStrict[convertTestStrictTarget](source, &StrictConvertOpts{AllowDropped: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "Jim", out.Name)
+	assert.Equal(t, []convertTestStrictTargetItem{{SKU: "a"}}, out.Items)
+	assert.Len(t, report.DroppedFields, 2)
+
+	_, _, err = JSONConvertStrict[convertTestStrictTarget](source, &StrictConvertOpts{AllowDropped: true, RequireAll: true})
+	assert.Error(t, err)
+
+	clean, report, err := JSONConvertStrict[convertTestStrictItem](map[string]any{"sku": "b", "price": 2})
+	assert.NoError(t, err)
+	assert.True(t, report.Clean())
+	assert.Equal(t, convertTestStrictItem{SKU: "b", Price: 2}, clean)
+
+	_, _, err = JSONConvertStrict[convertTestStrictItem](map[string]any{"price": "two"}, &StrictConvertOpts{AllowDropped: true})
+	assert.Error(t, err)
+
+	_, _, err = JSONConvertStrict[convertTestStrictItem](make(chan int))
+	assert.Error(t, err)
+}
+
+func TestJSONConvert(t *testing.T) {
+	t.Parallel()
+
+	out, ok, err := JSONConvert[convertTestStrictItem](map[string]any{"sku": "c", "price": 3, "extra": true})
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, convertTestStrictItem{SKU: "c", Price: 3}, out)
+
+	_, ok, err = JSONConvert[convertTestStrictItem]([]int{1})
+	assert.Error(t, err)
+	assert.False(t, ok)
+}
@@ -19,4 +19,9 @@ const (
 	CodeInvalidInput        = "invalid_input"
 	CodeInvalidAction       = "invalid_action"
 	CodeOperationFailed     = "operation_failed"
+	CodeMissingToken        = "missing_token"
+	CodeMalformedToken      = "malformed_token"
+	CodeTokenExpired        = "token_expired"
+	CodeInvalidToken        = "invalid_token"
+	CodeInvalidCSRFToken    = "invalid_csrf_token"
 )
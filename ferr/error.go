@@ -4,13 +4,15 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr/valid"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/iancoleman/strcase"
 	"go.temporal.io/api/enums/v1"
 	"go.temporal.io/sdk/temporal"
 	"net/http"
+	"sort"
 	"strings"
 )
 
@@ -44,6 +46,30 @@ type Error struct {
 	Fields []*FieldError `json:"fields,omitempty"`
 }
 
+// validationTranslator is used by Infer to translate validator.ValidationErrors into field errors
+var validationTranslator = newEnglishTranslator()
+
+// ValidationTranslator returns the translator Infer uses for validator.ValidationErrors, english by default
+// validators must register their translations with it for Infer to return translated messages,
+// ie. en.RegisterDefaultTranslations(v, ferr.ValidationTranslator()), the valid package does this for its validator
+// errors of validators without translations keep their raw validator messages
+func ValidationTranslator() ut.Translator {
+	return validationTranslator
+}
+
+// RegisterValidationTranslator replaces the translator Infer uses for validator.ValidationErrors
+func RegisterValidationTranslator(trans ut.Translator) {
+	validationTranslator = trans
+}
+
+func newEnglishTranslator() ut.Translator {
+	english := en.New()
+
+	trans, _ := ut.New(english, english).GetTranslator("en")
+
+	return trans
+}
+
 // New creates a new Error with a message, code, and type
 func New(eType ErrorType, code Code, msg string) *Error {
 	return &Error{
@@ -144,9 +170,19 @@ func Infer(err error) *Error {
 		fe := New(ETValidation, CodeInvalidInput, "Your input was invalid.").
 			WithHTTPCode(http.StatusBadRequest)
 
-		translated := validationError.Translate(valid.UniversalTranslator)
+		translated := validationError.Translate(validationTranslator)
+
+		// fields are sorted so the errors are in the same order on every call
+		names := make([]string, 0, len(translated))
+		for name := range translated {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for _, field := range names {
+			message := translated[field]
 
-		for field, message := range translated {
 			// field starts with the struct name, followed by a dot, so it should be removed
 			field = strcase.ToSnakeWithIgnore(strings.Join(strings.Split(field, ".")[1:], "."), ".")
 
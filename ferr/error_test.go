@@ -0,0 +1,43 @@
+package ferr
+
+import (
+	"github.com/go-playground/validator/v10"
+	en2 "github.com/go-playground/validator/v10/translations/en"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+type inferTestRequest struct {
+	FirstName string `validate:"required"`
+	Age       int    `validate:"gte=18"`
+}
+
+func TestInferValidationErrors(t *testing.T) {
+	t.Parallel()
+
+	// the valid package isn't imported here, so Infer only has its default translator
+	v := validator.New()
+	assert.NoError(t, en2.RegisterDefaultTranslations(v, ValidationTranslator()))
+
+	fe := Infer(v.Struct(&inferTestRequest{Age: 3}))
+
+	assert.Equal(t, Code(CodeInvalidInput), fe.Code)
+	assert.Equal(t, http.StatusBadRequest, *fe.HTTPCode)
+
+	if assert.Len(t, fe.Fields, 2) {
+		assert.Equal(t, "age", fe.Fields[0].Field)
+		assert.Equal(t, "Age must be 18 or greater", fe.Fields[0].Message)
+		assert.Equal(t, "first_name", fe.Fields[1].Field)
+		assert.Equal(t, "FirstName is a required field", fe.Fields[1].Message)
+	}
+
+	t.Run("validators without translations keep raw messages", func(t *testing.T) {
+		fe := Infer(validator.New().Struct(&inferTestRequest{Age: 20}))
+
+		if assert.Len(t, fe.Fields, 1) {
+			assert.Equal(t, "first_name", fe.Fields[0].Field)
+			assert.Contains(t, fe.Fields[0].Message, "'required' tag")
+		}
+	})
+}
@@ -11,6 +11,21 @@ var AccountExists = New(ETValidation, CodeAccountExists, "that account already e
 var Unauthenticated = New(ETAuth, CodeNotAuthenticated, "no valid authentication was found").
 	WithHTTPCode(http.StatusUnauthorized)
 
+var MissingToken = New(ETAuth, CodeMissingToken, "no authentication token was provided").
+	WithHTTPCode(http.StatusUnauthorized)
+
+var MalformedToken = New(ETAuth, CodeMalformedToken, "the authentication token is malformed").
+	WithHTTPCode(http.StatusBadRequest)
+
+var TokenExpired = New(ETAuth, CodeTokenExpired, "the authentication token has expired").
+	WithHTTPCode(http.StatusUnauthorized)
+
+var InvalidToken = New(ETAuth, CodeInvalidToken, "the authentication token is invalid").
+	WithHTTPCode(http.StatusUnauthorized)
+
+var InvalidCSRFToken = New(ETPermissions, CodeInvalidCSRFToken, "the request is missing a valid CSRF token").
+	WithHTTPCode(http.StatusForbidden)
+
 var AccountDisabled = New(ETPermissions, CodeAccountDisabled, "this account is disabled").
 	WithHTTPCode(http.StatusForbidden)
 
@@ -45,6 +60,19 @@ var InvalidArgument = func(resourceType string, reason ...string) error {
 	return WrapWithOffset(err, 2)
 }
 
+// InvalidFields builds a validation error for the given fields, in the same shape Infer produces for validator.ValidationErrors
+var InvalidFields = func(fieldErrors ...*FieldError) *Error {
+	err := New(ETValidation, CodeInvalidInput, "Your input was invalid.").
+		WithHTTPCode(http.StatusBadRequest)
+
+	for _, fieldError := range fieldErrors {
+		err = err.WithFieldError(fieldError)
+		err.Message += fmt.Sprintf("\n- %s", fieldError.Message)
+	}
+
+	return err
+}
+
 var InvalidAction = func(resourceType string, reason ...string) error {
 	err := New(ETValidation, CodeInvalidAction, fmt.Sprintf("invalid action: %s, with reasons: %+v", resourceType, reason)).
 		WithHTTPCode(http.StatusBadRequest)
@@ -0,0 +1,468 @@
+package valid
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/iancoleman/strcase"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	queryTag  = "query"
+	paramsTag = "params"
+	headerTag = "reqHeader"
+)
+
+// BindOptions changes how Bind reads a request
+type BindOptions struct {
+	// Strict will report body and query fields that do not exist on the target struct as validation errors
+	// body fields are only checked for json bodies
+	Strict bool
+}
+
+// bindSources records which parts of a request a struct type wants to be bound from
+type bindSources struct {
+	body       bool
+	query      bool
+	params     bool
+	headers    bool
+	queryNames map[string]bool
+
+	// nonBodyJSONNames holds the lowercased json names of fields bound from other sources, which strict mode
+	// rejects in the body. encoding/json matches names case-insensitively, so they are compared lowercased
+	nonBodyJSONNames map[string]bool
+}
+
+var bindSourcesCache sync.Map
+
+// Bind parses a request into a new T, applies its mod tags with Sanitize and then validates it with ValidateStruct
+// T must be a struct. Fields are read from the body using their json/form/xml tags, from the query string
+// using query tags, from path params using params tags, and from headers using reqHeader tags
+// Any failure is returned as a validation *ferr.Error, with snake_case field paths just like ferr.Infer
+func Bind[T any](c *fiber.Ctx, opts ...*BindOptions) (*T, error) {
+	options := &BindOptions{}
+	if len(opts) > 0 && opts[0] != nil {
+		options = opts[0]
+	}
+
+	var target T
+
+	sources := getBindSources(reflect.TypeOf(target))
+
+	if sources.body && len(c.Body()) > 0 {
+		if err := bindBody(c, &target, options.Strict, sources.nonBodyJSONNames); err != nil {
+			return nil, err
+		}
+	}
+
+	if sources.query {
+		queryArgs := c.Context().QueryArgs()
+
+		err := bindTagged(reflect.ValueOf(&target).Elem(), queryTag, func(name string) []string {
+			return bytesToStrings(queryArgs.PeekMulti(name))
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if options.Strict {
+			if err := checkUnknownQueryKeys(c, sources.queryNames); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if sources.params {
+		err := bindTagged(reflect.ValueOf(&target).Elem(), paramsTag, func(name string) []string {
+			return nonEmpty(c.Params(name))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if sources.headers {
+		err := bindTagged(reflect.ValueOf(&target).Elem(), headerTag, func(name string) []string {
+			return nonEmpty(c.Get(name))
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := Sanitize(&target); err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	if err := ValidateStruct(c.UserContext(), &target); err != nil {
+		return nil, ferr.Infer(err)
+	}
+
+	return &target, nil
+}
+
+func getBindSources(t reflect.Type) *bindSources {
+	if cached, ok := bindSourcesCache.Load(t); ok {
+		return cached.(*bindSources)
+	}
+
+	sources := &bindSources{queryNames: map[string]bool{}, nonBodyJSONNames: map[string]bool{}}
+	collectBindSources(t, sources)
+
+	bindSourcesCache.Store(t, sources)
+
+	return sources
+}
+
+func collectBindSources(t reflect.Type, sources *bindSources) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("valid.Bind target must be a struct, got %s", t))
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			collectBindSources(field.Type, sources)
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		hasSourceTag := false
+
+		if name := tagName(field, queryTag); name != "" {
+			sources.query = true
+			sources.queryNames[name] = true
+			hasSourceTag = true
+		}
+
+		if tagName(field, paramsTag) != "" {
+			sources.params = true
+			hasSourceTag = true
+		}
+
+		if tagName(field, headerTag) != "" {
+			sources.headers = true
+			hasSourceTag = true
+		}
+
+		if !hasSourceTag {
+			sources.body = true
+		} else if name := jsonName(field); name != "" {
+			sources.nonBodyJSONNames[strings.ToLower(name)] = true
+		}
+	}
+}
+
+// jsonName is the name encoding/json decodes a field from, or an empty string if it never decodes it
+func jsonName(field reflect.StructField) string {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return ""
+	}
+
+	if name := strings.Split(tag, ",")[0]; name != "" {
+		return name
+	}
+
+	return field.Name
+}
+
+func tagName(field reflect.StructField, tag string) string {
+	name := strings.Split(field.Tag.Get(tag), ",")[0]
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+func bindBody(c *fiber.Ctx, out any, strict bool, nonBodyJSONNames map[string]bool) error {
+	contentType := utils.ParseVendorSpecificContentType(strings.ToLower(string(c.Request().Header.ContentType())))
+
+	// Fiber's body parser can't reject unknown fields, so strict json bodies are decoded here instead
+	if strict && strings.HasPrefix(contentType, fiber.MIMEApplicationJSON) {
+		if err := checkNonBodyKeys(c.Body(), nonBodyJSONNames); err != nil {
+			return err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(c.Body()))
+		decoder.DisallowUnknownFields()
+
+		if err := decoder.Decode(out); err != nil {
+			return bindError(err)
+		}
+
+		return nil
+	}
+
+	if err := c.BodyParser(out); err != nil {
+		return bindError(err)
+	}
+
+	return nil
+}
+
+// bindTagged sets every field of target that has tag from the values returned by lookup
+// Fiber's query and header parsers share a decoder cache that ignores the alias tag, so they can't be used
+// on the same struct, these sources are bound here instead
+func bindTagged(target reflect.Value, tag string, lookup func(name string) []string) error {
+	t := target.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			if err := bindTagged(target.Field(i), tag, lookup); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		name := tagName(field, tag)
+		if name == "" || !field.IsExported() {
+			continue
+		}
+
+		values := lookup(name)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := setFromStrings(target.Field(i), values); err != nil {
+			return ferr.InvalidFields(&ferr.FieldError{
+				Field:   strcase.ToSnake(field.Name),
+				Message: fmt.Sprintf("%s is not a valid %s", name, field.Type),
+			})
+		}
+	}
+
+	return nil
+}
+
+// setFromStrings sets a reflected value from one or more strings, slices accept repeated or comma separated values
+func setFromStrings(value reflect.Value, raw []string) error {
+	_, isTextUnmarshaler := value.Addr().Interface().(encoding.TextUnmarshaler)
+
+	if value.Kind() != reflect.Slice || isTextUnmarshaler {
+		return setFromString(value, raw[len(raw)-1])
+	}
+
+	var items []string
+
+	for _, r := range raw {
+		items = append(items, strings.Split(r, ",")...)
+	}
+
+	slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+
+	for i, item := range items {
+		if err := setFromString(slice.Index(i), item); err != nil {
+			return err
+		}
+	}
+
+	value.Set(slice)
+
+	return nil
+}
+
+// setFromString sets a reflected value from its string representation
+func setFromString(value reflect.Value, raw string) error {
+	if value.CanAddr() {
+		if unmarshaler, ok := value.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return unmarshaler.UnmarshalText([]byte(raw))
+		}
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		value.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+
+		value.SetBool(b)
+	case reflect.Ptr:
+		elem := reflect.New(value.Type().Elem())
+
+		if err := setFromString(elem.Elem(), raw); err != nil {
+			return err
+		}
+
+		value.Set(elem)
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+
+	return nil
+}
+
+// checkNonBodyKeys rejects body keys of fields that are bound from the query, params or headers
+// the json decoder would otherwise set them, letting the body stand in for those sources
+func checkNonBodyKeys(body []byte, nonBodyJSONNames map[string]bool) error {
+	if len(nonBodyJSONNames) == 0 {
+		return nil
+	}
+
+	var keys map[string]json.RawMessage
+
+	// bodies that aren't objects are reported by the decoder
+	if err := json.Unmarshal(body, &keys); err != nil {
+		return nil
+	}
+
+	var fieldErrors []*ferr.FieldError
+
+	for key := range keys {
+		if nonBodyJSONNames[strings.ToLower(key)] {
+			fieldErrors = append(fieldErrors, unknownFieldError(key))
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		sort.Slice(fieldErrors, func(i, j int) bool { return fieldErrors[i].Field < fieldErrors[j].Field })
+		return ferr.InvalidFields(fieldErrors...)
+	}
+
+	return nil
+}
+
+func checkUnknownQueryKeys(c *fiber.Ctx, known map[string]bool) error {
+	var fieldErrors []*ferr.FieldError
+
+	c.Context().QueryArgs().VisitAll(func(key, _ []byte) {
+		if !known[string(key)] {
+			fieldErrors = append(fieldErrors, unknownFieldError(string(key)))
+		}
+	})
+
+	if len(fieldErrors) > 0 {
+		return ferr.InvalidFields(fieldErrors...)
+	}
+
+	return nil
+}
+
+// bindError converts an error from one of the request parsers into a validation error
+func bindError(err error) error {
+	var jsonTypeError *json.UnmarshalTypeError
+	if errors.As(err, &jsonTypeError) {
+		return ferr.InvalidFields(&ferr.FieldError{
+			Field:   strcase.ToSnakeWithIgnore(jsonTypeError.Field, "."),
+			Message: jsonTypeError.Error(),
+		})
+	}
+
+	// form bodies are decoded by fiber's schema decoder
+	var multiError fiber.MultiError
+	if errors.As(err, &multiError) {
+		var fieldErrors []*ferr.FieldError
+
+		for key, keyErr := range multiError {
+			fieldErrors = append(fieldErrors, &ferr.FieldError{
+				Field:   strcase.ToSnakeWithIgnore(key, "."),
+				Message: keyErr.Error(),
+			})
+		}
+
+		return ferr.InvalidFields(fieldErrors...)
+	}
+
+	var conversionError fiber.ConversionError
+	if errors.As(err, &conversionError) {
+		return ferr.InvalidFields(&ferr.FieldError{
+			Field:   strcase.ToSnakeWithIgnore(conversionError.Key, "."),
+			Message: conversionError.Error(),
+		})
+	}
+
+	// encoding/json does not export a type for unknown field errors
+	if unknownField := strings.TrimPrefix(err.Error(), "json: unknown field "); unknownField != err.Error() {
+		return ferr.InvalidFields(unknownFieldError(strings.Trim(unknownField, `"`)))
+	}
+
+	if errors.Is(err, fiber.ErrUnprocessableEntity) {
+		return ferr.New(ferr.ETValidation, ferr.CodeInvalidInput, "unsupported request content type").
+			WithHTTPCode(http.StatusUnsupportedMediaType).
+			WithUnderlying(err)
+	}
+
+	inferred := ferr.Infer(err)
+	if inferred.Type == ferr.ETValidation {
+		return inferred
+	}
+
+	return ferr.New(ferr.ETValidation, ferr.CodeInvalidInput, fmt.Sprintf("could not parse request: %s", err.Error())).
+		WithHTTPCode(http.StatusBadRequest).
+		WithUnderlying(err)
+}
+
+func bytesToStrings(values [][]byte) []string {
+	var strs []string
+
+	for _, v := range values {
+		strs = append(strs, string(v))
+	}
+
+	return strs
+}
+
+func nonEmpty(value string) []string {
+	if value == "" {
+		return nil
+	}
+
+	return []string{value}
+}
+
+// unknownFieldError reports a key of the request that no field binds from
+// the field is snake_cased like every other field error, the message keeps the key as it was sent
+func unknownFieldError(key string) *ferr.FieldError {
+	return &ferr.FieldError{
+		Field:   strcase.ToSnakeWithIgnore(key, "."),
+		Message: fmt.Sprintf("%s is not a recognized field", key),
+	}
+}
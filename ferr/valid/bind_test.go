@@ -0,0 +1,112 @@
+package valid
+
+import (
+	"encoding/json"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type bindTestRequest struct {
+	ID        int    `params:"id" validate:"required"`
+	Verbose   bool   `query:"verbose"`
+	RequestID string `reqHeader:"X-Request-Id"`
+	FirstName string `json:"first_name" mod:"trim" validate:"required,simpletext"`
+}
+
+func bindTestApp(opts *BindOptions) *fiber.App {
+	app := fiber.New()
+	app.Use(ferr.Middleware(false))
+
+	app.Post("/users/:id", func(c *fiber.Ctx) error {
+		req, err := Bind[bindTestRequest](c, opts)
+		if err != nil {
+			return err
+		}
+
+		return c.JSON(req)
+	})
+
+	return app
+}
+
+func doBindRequest(t *testing.T, app *fiber.App, target, body string) (int, map[string]any) {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", fiber.MIMEApplicationJSON)
+	req.Header.Set("X-Request-Id", "abc")
+
+	res, err := app.Test(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]any
+
+	err = json.NewDecoder(res.Body).Decode(&decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res.StatusCode, decoded
+}
+
+func TestBind(t *testing.T) {
+	t.Parallel()
+
+	t.Run("all sources", func(t *testing.T) {
+		status, body := doBindRequest(t, bindTestApp(nil), "/users/12?verbose=true", `{"first_name": "Jim"}`)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, float64(12), body["ID"])
+		assert.Equal(t, true, body["Verbose"])
+		assert.Equal(t, "abc", body["RequestID"])
+		assert.Equal(t, "Jim", body["first_name"])
+	})
+
+	t.Run("mod tags are applied", func(t *testing.T) {
+		status, body := doBindRequest(t, bindTestApp(nil), "/users/12", `{"first_name": "  Jim  "}`)
+
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "Jim", body["first_name"])
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		status, body := doBindRequest(t, bindTestApp(nil), "/users/12", `{"first_name": "J!m"}`)
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "first_name", body["fields"].([]any)[0].(map[string]any)["field"])
+	})
+
+	t.Run("invalid param", func(t *testing.T) {
+		status, body := doBindRequest(t, bindTestApp(nil), "/users/abc", `{"first_name": "Jim"}`)
+
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "id", body["fields"].([]any)[0].(map[string]any)["field"])
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		app := bindTestApp(&BindOptions{Strict: true})
+
+		status, body := doBindRequest(t, app, "/users/12", `{"first_name": "Jim", "lastName": "Bob"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "last_name", body["fields"].([]any)[0].(map[string]any)["field"])
+		assert.Equal(t, "lastName is not a recognized field", body["fields"].([]any)[0].(map[string]any)["message"])
+
+		status, body = doBindRequest(t, app, "/users/12?pageSize=2", `{"first_name": "Jim"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+		assert.Equal(t, "page_size", body["fields"].([]any)[0].(map[string]any)["field"])
+
+		// fields bound from params, query and headers can't be set from the body
+		status, body = doBindRequest(t, app, "/users/12", `{"first_name": "Jim", "ID": 99, "requestid": "xyz"}`)
+		assert.Equal(t, http.StatusBadRequest, status)
+
+		if fields, ok := body["fields"].([]any); assert.True(t, ok) && assert.Len(t, fields, 2) {
+			assert.Equal(t, "id", fields[0].(map[string]any)["field"])
+			assert.Equal(t, "requestid", fields[1].(map[string]any)["field"])
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package valid
+
+import (
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/maybe"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/iancoleman/strcase"
+	"reflect"
+	"strings"
+)
+
+// Rules for groups of optional fields, such as maybe.Maybe, maybe.Patch, null types and pointers
+// each rule is placed on one field of the group, and its params are the struct field names of the other fields
+// maybe.Maybe and maybe.Patch fields of types other than the builtin ones need RegisterMaybeType for their tags to run
+//
+//	Email maybe.Maybe[string] `validate:"exactly_one_of=Phone"`
+//	Phone maybe.Maybe[string]
+const (
+	tagExactlyOneOf         = "exactly_one_of"
+	tagAtMostOneOf          = "at_most_one_of"
+	tagRequiredWithMaybe    = "required_with_maybe"
+	tagRequiredWithoutMaybe = "required_without_maybe"
+)
+
+func registerMaybeRules(trans ut.Translator) {
+	rules := []struct {
+		tag         string
+		fn          validator.Func
+		translation string
+	}{
+		{tagExactlyOneOf, validateExactlyOneOf, "exactly one of {0}, {1} must be set"},
+		{tagAtMostOneOf, validateAtMostOneOf, "only one of {0}, {1} can be set"},
+		{tagRequiredWithMaybe, validateRequiredWithMaybe, "{0} is required when {1} is set"},
+		{tagRequiredWithoutMaybe, validateRequiredWithoutMaybe, "{0} is required when {1} is not set"},
+	}
+
+	for _, rule := range rules {
+		// these need to run even when the field is empty, which validator represents as nil
+		err := validate.RegisterValidation(rule.tag, rule.fn, true)
+		if err != nil {
+			panic(err)
+		}
+
+		err = validate.RegisterTranslation(rule.tag, trans, registerTranslationFunc(rule.tag, rule.translation), translateGroupRule)
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func registerTranslationFunc(tag, translation string) validator.RegisterTranslationsFunc {
+	return func(ut ut.Translator) error {
+		return ut.Add(tag, translation, true)
+	}
+}
+
+// translateGroupRule fills in the field name, and the snake_case names of the other fields in the group
+func translateGroupRule(ut ut.Translator, fe validator.FieldError) string {
+	others := strings.Fields(fe.Param())
+	for i, other := range others {
+		others[i] = strcase.ToSnake(other)
+	}
+
+	t, err := ut.T(fe.Tag(), strcase.ToSnake(fe.Field()), strings.Join(others, ", "))
+	if err != nil {
+		return fe.Error()
+	}
+
+	return t
+}
+
+func validateExactlyOneOf(fl validator.FieldLevel) bool {
+	return countGroupSet(fl) == 1
+}
+
+func validateAtMostOneOf(fl validator.FieldLevel) bool {
+	return countGroupSet(fl) <= 1
+}
+
+func validateRequiredWithMaybe(fl validator.FieldLevel) bool {
+	parent := reflect.Indirect(fl.Parent())
+
+	for _, other := range strings.Fields(fl.Param()) {
+		if isFieldSet(parent.FieldByName(other)) {
+			return isFieldSet(parent.FieldByName(fl.StructFieldName()))
+		}
+	}
+
+	return true
+}
+
+func validateRequiredWithoutMaybe(fl validator.FieldLevel) bool {
+	parent := reflect.Indirect(fl.Parent())
+
+	for _, other := range strings.Fields(fl.Param()) {
+		if !isFieldSet(parent.FieldByName(other)) {
+			return isFieldSet(parent.FieldByName(fl.StructFieldName()))
+		}
+	}
+
+	return true
+}
+
+// countGroupSet counts how many fields in the group are set, including the field the tag is on
+func countGroupSet(fl validator.FieldLevel) int {
+	parent := reflect.Indirect(fl.Parent())
+
+	count := 0
+
+	for _, name := range append([]string{fl.StructFieldName()}, strings.Fields(fl.Param())...) {
+		if isFieldSet(parent.FieldByName(name)) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// isFieldSet checks the raw struct field, before any custom type funcs have been applied
+// unknown field names are treated as not set
+func isFieldSet(field reflect.Value) bool {
+	if !field.IsValid() {
+		return false
+	}
+
+	if field.CanInterface() {
+		if ish, ok := field.Interface().(maybe.Ish); ok {
+			return ish.HasValue()
+		}
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Chan, reflect.Func:
+		return !field.IsNil()
+	case reflect.Struct:
+		// null types, and sql.Null* types
+		if valid := field.FieldByName("Valid"); valid.IsValid() && valid.Kind() == reflect.Bool {
+			return valid.Bool()
+		}
+	}
+
+	return !field.IsZero()
+}
@@ -0,0 +1,117 @@
+package valid
+
+import (
+	"context"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/maybe"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type maybeRulesTestRequest struct {
+	Email    maybe.Maybe[string] `validate:"exactly_one_of=Phone"`
+	Phone    maybe.Maybe[string]
+	Nickname maybe.Maybe[string] `validate:"required_with_maybe=Email"`
+}
+
+func TestMaybeRules(t *testing.T) {
+	t.Parallel()
+
+	err := ValidateStruct(context.Background(), maybeRulesTestRequest{
+		Phone: maybe.WithValue("555-5555"),
+	})
+	assert.NoError(t, err)
+
+	err = ValidateStruct(context.Background(), maybeRulesTestRequest{})
+	if assert.Error(t, err) {
+		fe := ferr.Infer(err)
+
+		assert.Len(t, fe.Fields, 1)
+		assert.Equal(t, "email", fe.Fields[0].Field)
+		assert.Equal(t, "exactly one of email, phone must be set", fe.Fields[0].Message)
+	}
+
+	err = ValidateStruct(context.Background(), maybeRulesTestRequest{
+		Email: maybe.WithValue("a@b.com"),
+		Phone: maybe.WithValue("555-5555"),
+	})
+	if assert.Error(t, err) {
+		fe := ferr.Infer(err)
+
+		assert.Len(t, fe.Fields, 2)
+	}
+}
+
+type maybeRulesTestLevel string
+
+func init() {
+	RegisterMaybeType[maybeRulesTestLevel]()
+}
+
+type maybeRulesIntRequest struct {
+	UserID  maybe.Maybe[int] `validate:"exactly_one_of=GroupID"`
+	GroupID maybe.Maybe[int]
+}
+
+type maybeRulesBoolRequest struct {
+	Archived maybe.Maybe[bool] `validate:"at_most_one_of=Deleted"`
+	Deleted  maybe.Maybe[bool]
+}
+
+type maybeRulesPatchRequest struct {
+	Name  maybe.Patch[int]                 `validate:"exactly_one_of=Level"`
+	Level maybe.Patch[maybeRulesTestLevel] `validate:"required_without_maybe=Name"`
+}
+
+func TestMaybeRulesTypes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		request any
+		fields  []string
+	}{
+		{"int one set", maybeRulesIntRequest{GroupID: maybe.WithValue(1)}, nil},
+		{"int none set", maybeRulesIntRequest{}, []string{"user_id"}},
+		{"int both set", maybeRulesIntRequest{UserID: maybe.WithValue(1), GroupID: maybe.WithValue(2)}, []string{"user_id"}},
+		{"bool none set", maybeRulesBoolRequest{}, nil},
+		{"bool one set", maybeRulesBoolRequest{Deleted: maybe.WithValue(false)}, nil},
+		{"bool both set", maybeRulesBoolRequest{Archived: maybe.WithValue(true), Deleted: maybe.WithValue(false)}, []string{"archived"}},
+		{"patch one set", maybeRulesPatchRequest{Level: maybe.PatchWithValue[maybeRulesTestLevel]("admin")}, nil},
+		{"patch null is not set", maybeRulesPatchRequest{Name: maybe.PatchNull[int](), Level: maybe.PatchNull[maybeRulesTestLevel]()}, []string{"name", "level"}},
+		{"patch none set", maybeRulesPatchRequest{}, []string{"name", "level"}},
+		{"patch both set", maybeRulesPatchRequest{Name: maybe.PatchWithValue(1), Level: maybe.PatchWithValue[maybeRulesTestLevel]("admin")}, []string{"name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStruct(context.Background(), tt.request)
+
+			if tt.fields == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			if assert.Error(t, err) {
+				var fields []string
+				for _, field := range ferr.Infer(err).Fields {
+					fields = append(fields, field.Field)
+				}
+
+				assert.ElementsMatch(t, tt.fields, fields)
+			}
+		})
+	}
+
+	t.Run("field errors are sorted", func(t *testing.T) {
+		err := ValidateStruct(context.Background(), maybeRulesPatchRequest{})
+
+		for i := 0; i < 20; i++ {
+			fields := ferr.Infer(err).Fields
+			if assert.Len(t, fields, 2) {
+				assert.Equal(t, "level", fields[0].Field)
+				assert.Equal(t, "name", fields[1].Field)
+			}
+		}
+	})
+}
@@ -0,0 +1,255 @@
+package valid
+
+import (
+	"fmt"
+	"github.com/iancoleman/strcase"
+	"github.com/volatiletech/null/v8"
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// ModifierFunc normalizes a single string value
+type ModifierFunc func(s string) string
+
+// stringSettable is implemented by *maybe.Maybe[string] and *maybe.Patch[string]
+type stringSettable interface {
+	Value() (string, bool)
+	Set(val string)
+}
+
+var (
+	modifiersMu sync.RWMutex
+	modifiers   = map[string]ModifierFunc{
+		"trim":       strings.TrimSpace,
+		"ltrim":      func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) },
+		"rtrim":      func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) },
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"title":      toTitle,
+		"snake":      strcase.ToSnake,
+		"camel":      strcase.ToLowerCamel,
+		"kebab":      strcase.ToKebab,
+		"collapse":   collapseWhitespace,
+		"strip_html": stripHTML,
+	}
+
+	// structModsCache holds the parsed mod tags of each struct type, as a *structMods
+	// it is replaced when a modifier is registered, since tags may refer to it
+	structModsCache = &sync.Map{}
+
+	htmlTagRegex       = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlBlockRegex     = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	whitespaceRunRegex = regexp.MustCompile(`\s+`)
+)
+
+// structMods are the modifiers of every field of a struct type, by field index
+type structMods struct {
+	fields [][]ModifierFunc
+	err    error
+}
+
+// visitedPointer identifies a pointer that was already sanitized, the type is part of it since a struct
+// and its first field share an address
+type visitedPointer struct {
+	ptr uintptr
+	t   reflect.Type
+}
+
+// RegisterModifier adds a modifier that can be used in mod tags, existing modifiers with the same name are replaced
+func RegisterModifier(name string, fn ModifierFunc) {
+	modifiersMu.Lock()
+	defer modifiersMu.Unlock()
+
+	modifiers[name] = fn
+	structModsCache = &sync.Map{}
+}
+
+// Sanitize normalizes every string field of s that has a mod tag, modifiers are applied in the order they are listed
+//
+//	Email string              `mod:"trim,lower" validate:"email"`
+//	Bio   maybe.Maybe[string] `mod:"strip_html,collapse,trim"`
+//
+// string, *string, []string, null.String, maybe.Maybe[string] and maybe.Patch[string] fields are supported,
+// and nested structs are sanitized as well, each pointer only once. s must be a pointer to a struct
+// Bind calls this before validating, ValidateStruct doesn't
+func Sanitize(s any) error {
+	v := reflect.ValueOf(s)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("sanitize target must be a non nil pointer, got %T", s)
+	}
+
+	return sanitizeValue(v, nil, map[visitedPointer]bool{})
+}
+
+func sanitizeValue(v reflect.Value, mods []ModifierFunc, visited map[visitedPointer]bool) error {
+	if !v.IsValid() {
+		return nil
+	}
+
+	// values reached through unexported fields, such as an unexported embedded struct, can't be used as interfaces
+	// their exported fields are still settable, so they are sanitized below
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if settable, ok := v.Addr().Interface().(stringSettable); ok {
+			if val, ok := settable.Value(); ok && len(mods) > 0 {
+				settable.Set(applyModifiers(val, mods))
+			}
+
+			return nil
+		}
+
+		if ns, ok := v.Addr().Interface().(*null.String); ok {
+			if ns.Valid {
+				ns.String = applyModifiers(ns.String, mods)
+			}
+
+			return nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		if len(mods) > 0 && v.CanSet() {
+			v.SetString(applyModifiers(v.String(), mods))
+		}
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+
+		// self referencing structs would otherwise be walked forever
+		key := visitedPointer{ptr: v.Pointer(), t: v.Type()}
+		if visited[key] {
+			return nil
+		}
+
+		visited[key] = true
+
+		return sanitizeValue(v.Elem(), mods, visited)
+	case reflect.Interface:
+		if !v.IsNil() && v.Elem().CanAddr() {
+			return sanitizeValue(v.Elem(), mods, visited)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := sanitizeValue(v.Index(i), mods, visited); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return sanitizeStruct(v, visited)
+	}
+
+	return nil
+}
+
+func sanitizeStruct(v reflect.Value, visited map[visitedPointer]bool) error {
+	sm := getStructMods(v.Type())
+	if sm.err != nil {
+		return sm.err
+	}
+
+	for i, mods := range sm.fields {
+		field := v.Type().Field(i)
+
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		if err := sanitizeValue(v.Field(i), mods, visited); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getStructMods parses the mod tags of a struct type once, and caches them
+func getStructMods(t reflect.Type) *structMods {
+	modifiersMu.RLock()
+	cache := structModsCache
+	modifiersMu.RUnlock()
+
+	if cached, ok := cache.Load(t); ok {
+		return cached.(*structMods)
+	}
+
+	sm := &structMods{fields: make([][]ModifierFunc, t.NumField())}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		mods, err := parseModTag(field.Tag.Get("mod"))
+		if err != nil {
+			sm.err = fmt.Errorf("invalid mod tag on %s.%s: %w", t.Name(), field.Name, err)
+			break
+		}
+
+		sm.fields[i] = mods
+	}
+
+	cache.Store(t, sm)
+
+	return sm
+}
+
+func parseModTag(tag string) ([]ModifierFunc, error) {
+	if tag == "" || tag == "-" {
+		return nil, nil
+	}
+
+	modifiersMu.RLock()
+	defer modifiersMu.RUnlock()
+
+	var mods []ModifierFunc
+
+	for _, name := range strings.Split(tag, ",") {
+		mod, ok := modifiers[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown modifier %q", name)
+		}
+
+		mods = append(mods, mod)
+	}
+
+	return mods, nil
+}
+
+func applyModifiers(s string, mods []ModifierFunc) string {
+	for _, mod := range mods {
+		s = mod(s)
+	}
+
+	return s
+}
+
+// toTitle upper cases the first letter of every word, and lower cases the rest
+func toTitle(s string) string {
+	runes := []rune(strings.ToLower(s))
+
+	for i, r := range runes {
+		if i == 0 || unicode.IsSpace(runes[i-1]) || runes[i-1] == '-' {
+			runes[i] = unicode.ToUpper(r)
+		}
+	}
+
+	return string(runes)
+}
+
+func collapseWhitespace(s string) string {
+	return whitespaceRunRegex.ReplaceAllString(s, " ")
+}
+
+// stripHTML removes tags (and the content of script and style blocks), then unescapes html entities
+// tags are stripped again after unescaping, so escaped markup can't be used to smuggle tags through
+func stripHTML(s string) string {
+	s = htmlBlockRegex.ReplaceAllString(s, "")
+	s = htmlTagRegex.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	s = htmlBlockRegex.ReplaceAllString(s, "")
+
+	return htmlTagRegex.ReplaceAllString(s, "")
+}
@@ -0,0 +1,171 @@
+package valid
+
+import (
+	"context"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/maybe"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type sanitizeTestAddress struct {
+	City string `mod:"trim,title"`
+}
+
+type sanitizeTestAudit struct {
+	Note string `mod:"collapse,trim"`
+}
+
+type sanitizeTestRequest struct {
+	sanitizeTestAudit
+
+	Email     string              `mod:"trim,lower" validate:"email"`
+	Nickname  *string             `mod:"trim"`
+	Tags      []string            `mod:"trim,kebab"`
+	Alias     null.String         `mod:"upper"`
+	Bio       maybe.Maybe[string] `mod:"strip_html,collapse,trim"`
+	Handle    maybe.Patch[string] `mod:"snake"`
+	Missing   maybe.Maybe[string] `mod:"trim"`
+	Untouched string              `validate:"required"`
+	Address   sanitizeTestAddress
+	Previous  *sanitizeTestAddress
+	History   []sanitizeTestAddress
+	internal  string
+}
+
+func TestSanitize(t *testing.T) {
+	t.Parallel()
+
+	nickname := "  jim  "
+
+	req := &sanitizeTestRequest{
+		sanitizeTestAudit: sanitizeTestAudit{Note: "  a   b  "},
+		Email:             "  Jim@Example.COM ",
+		Nickname:          &nickname,
+		Tags:              []string{" Some Tag ", "otherTag"},
+		Alias:             null.StringFrom("jimbo"),
+		Bio:               maybe.WithValue(" <b>hi</b>   <script>alert(1)</script>there &lt;i&gt;x&lt;/i&gt; "),
+		Handle:            maybe.PatchWithValue("JimBob"),
+		Untouched:         "  As Is  ",
+		Address:           sanitizeTestAddress{City: " new york "},
+		Previous:          &sanitizeTestAddress{City: "SAN-FRANCISCO"},
+		History:           []sanitizeTestAddress{{City: " paris"}},
+		internal:          "  internal  ",
+	}
+
+	assert.NoError(t, Sanitize(req))
+
+	assert.Equal(t, "a b", req.Note)
+	assert.Equal(t, "jim@example.com", req.Email)
+	assert.Equal(t, "jim", nickname)
+	assert.Equal(t, []string{"some-tag", "other-tag"}, req.Tags)
+	assert.Equal(t, null.StringFrom("JIMBO"), req.Alias)
+	assert.Equal(t, maybe.WithValue("hi there x"), req.Bio)
+	assert.Equal(t, maybe.PatchWithValue("jim_bob"), req.Handle)
+	assert.False(t, req.Missing.HasValue())
+	assert.Equal(t, "  As Is  ", req.Untouched)
+	assert.Equal(t, "New York", req.Address.City)
+	assert.Equal(t, "San-Francisco", req.Previous.City)
+	assert.Equal(t, "Paris", req.History[0].City)
+	assert.Equal(t, "  internal  ", req.internal)
+
+	assert.Error(t, Sanitize(sanitizeTestRequest{}))
+	assert.Error(t, Sanitize((*sanitizeTestRequest)(nil)))
+
+	err := Sanitize(&struct {
+		Name string `mod:"trim,shout"`
+	}{})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), `unknown modifier "shout"`)
+	}
+}
+
+func TestRegisterModifier(t *testing.T) {
+	t.Parallel()
+
+	RegisterModifier("sanitize_test_reverse", func(s string) string {
+		runes := []rune(s)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+
+		return string(runes)
+	})
+
+	req := &struct {
+		Name string `mod:"sanitize_test_reverse,upper"`
+	}{Name: "abc"}
+
+	assert.NoError(t, Sanitize(req))
+	assert.Equal(t, "CBA", req.Name)
+}
+
+type sanitizeTestEmbedded struct {
+	Name string `mod:"trim" validate:"required"`
+}
+
+type sanitizeTestUnexportedEmbed struct {
+	sanitizeTestEmbedded
+	*sanitizeTestAddress
+}
+
+func TestSanitizeUnexportedEmbeds(t *testing.T) {
+	t.Parallel()
+
+	req := &sanitizeTestUnexportedEmbed{
+		sanitizeTestEmbedded: sanitizeTestEmbedded{Name: "  Jim  "},
+		sanitizeTestAddress:  &sanitizeTestAddress{City: "paris"},
+	}
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, Sanitize(req))
+	})
+
+	assert.Equal(t, "Jim", req.Name)
+	assert.Equal(t, "Paris", req.City)
+
+	blank := &sanitizeTestUnexportedEmbed{sanitizeTestEmbedded: sanitizeTestEmbedded{Name: strings.Repeat(" ", 3)}}
+
+	assert.NoError(t, Sanitize(blank))
+	assert.Error(t, ValidateStruct(context.Background(), blank))
+}
+
+func TestValidateStructDoesNotSanitize(t *testing.T) {
+	t.Parallel()
+
+	req := &sanitizeTestEmbedded{Name: "  Jim  "}
+
+	assert.NoError(t, ValidateStruct(context.Background(), req))
+	assert.Equal(t, "  Jim  ", req.Name)
+}
+
+type sanitizeTestNode struct {
+	Name     string `mod:"trim"`
+	Parent   *sanitizeTestNode
+	Children []*sanitizeTestNode
+}
+
+func TestSanitizeCycles(t *testing.T) {
+	t.Parallel()
+
+	root := &sanitizeTestNode{Name: " root "}
+	child := &sanitizeTestNode{Name: " child ", Parent: root}
+	root.Children = []*sanitizeTestNode{child, child}
+	root.Parent = root
+
+	assert.NoError(t, Sanitize(root))
+	assert.Equal(t, "root", root.Name)
+	assert.Equal(t, "child", child.Name)
+}
+
+// not parallel, TestRegisterModifier resets the cache
+func TestSanitizeCachesTags(t *testing.T) {
+	assert.NoError(t, Sanitize(&sanitizeTestAddress{City: "a"}))
+
+	cached, ok := structModsCache.Load(reflect.TypeOf(sanitizeTestAddress{}))
+	if assert.True(t, ok) {
+		assert.Len(t, cached.(*structMods).fields[0], 2)
+	}
+}
@@ -0,0 +1,458 @@
+package valid
+
+import (
+	"encoding/json"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/maybe"
+	"github.com/google/uuid"
+	"github.com/volatiletech/null/v8"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaDialect selects the flavour of schema produced by a SchemaGenerator
+type SchemaDialect string
+
+const (
+	// DialectJSONSchema produces JSON Schema draft 2020-12, nullable values are expressed as a type union with null
+	DialectJSONSchema = SchemaDialect("https://json-schema.org/draft/2020-12/schema")
+
+	// DialectOpenAPI30 produces OpenAPI 3.0 component schemas, nullable values use the nullable keyword
+	DialectOpenAPI30 = SchemaDialect("openapi-3.0")
+)
+
+// Schema is a JSON Schema / OpenAPI schema object
+type Schema struct {
+	Schema string `json:"$schema,omitempty"`
+	Ref    string `json:"$ref,omitempty"`
+
+	// Type is either a string, or a list of strings when a 2020-12 schema is nullable
+	Type   any    `json:"type,omitempty"`
+	Format string `json:"format,omitempty"`
+
+	Nullable bool  `json:"nullable,omitempty"`
+	Enum     []any `json:"enum,omitempty"`
+
+	Pattern   string `json:"pattern,omitempty"`
+	MinLength *int   `json:"minLength,omitempty"`
+	MaxLength *int   `json:"maxLength,omitempty"`
+
+	Minimum          *float64 `json:"minimum,omitempty"`
+	Maximum          *float64 `json:"maximum,omitempty"`
+	ExclusiveMinimum *float64 `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum *float64 `json:"exclusiveMaximum,omitempty"`
+
+	Items    *Schema `json:"items,omitempty"`
+	MinItems *int    `json:"minItems,omitempty"`
+	MaxItems *int    `json:"maxItems,omitempty"`
+
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+
+	// AllOf and AnyOf are used to attach nullability to a $ref
+	AllOf []*Schema `json:"allOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+}
+
+// SchemaGenerator reflects over structs used with ValidateStruct and produces schemas for them
+// every named struct type is emitted once as a component and referenced from everywhere it is used
+type SchemaGenerator struct {
+	dialect    SchemaDialect
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	maybePkgPath   = reflect.TypeOf(maybe.Maybe[int]{}).PkgPath()
+	nullPkgPath    = reflect.TypeOf(null.String{}).PkgPath()
+	nullJSONType   = reflect.TypeOf(null.JSON{})
+
+	componentNameRegex = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+)
+
+func NewSchemaGenerator(dialect SchemaDialect) *SchemaGenerator {
+	return &SchemaGenerator{
+		dialect:    dialect,
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+// GenerateJSONSchema produces a standalone draft 2020-12 document for v, nested structs are placed in $defs
+func GenerateJSONSchema(v any) *Schema {
+	g := NewSchemaGenerator(DialectJSONSchema)
+
+	root := g.Add(v)
+	if root.Ref != "" {
+		component := *g.components[strings.TrimPrefix(root.Ref, g.refPrefix())]
+		root = &component
+	}
+
+	root.Schema = string(DialectJSONSchema)
+
+	if len(g.components) > 0 {
+		root.Defs = g.components
+	}
+
+	return root
+}
+
+// Add generates a schema for v, registering it and any nested struct types as components
+// the returned schema is a reference to the component when v is a named struct
+func (g *SchemaGenerator) Add(v any) *Schema {
+	return g.schemaFor(indirectType(reflect.TypeOf(v)))
+}
+
+// Components returns every component generated so far, keyed by name
+// for DialectOpenAPI30 this is the content of components.schemas
+func (g *SchemaGenerator) Components() map[string]*Schema {
+	return g.components
+}
+
+func (g *SchemaGenerator) refPrefix() string {
+	if g.dialect == DialectOpenAPI30 {
+		return "#/components/schemas/"
+	}
+
+	return "#/$defs/"
+}
+
+//revive:disable:cyclomatic A switch over every kind is the clearest way to write this
+func (g *SchemaGenerator) schemaFor(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case t == rawMessageType || t == nullJSONType:
+		return &Schema{}
+	}
+
+	if inner, ok := optionalInnerType(t); ok {
+		return g.nullable(g.schemaFor(inner))
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.nullable(g.schemaFor(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		return &Schema{Ref: g.refPrefix() + g.component(t)}
+	default:
+		return &Schema{}
+	}
+}
+
+// component registers a named struct type as a component and returns its name
+func (g *SchemaGenerator) component(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+
+	name := strings.Trim(componentNameRegex.ReplaceAllString(t.Name(), "_"), "_")
+
+	for i := 2; g.components[name] != nil; i++ {
+		name = strings.Trim(componentNameRegex.ReplaceAllString(t.Name(), "_"), "_") + strconv.Itoa(i)
+	}
+
+	g.names[t] = name
+
+	// reserve the name before recursing so self-referencing types terminate
+	g.components[name] = &Schema{}
+	*g.components[name] = *g.structSchema(t)
+
+	return name
+}
+
+func (g *SchemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	g.addFields(s, t)
+
+	sort.Strings(s.Required)
+
+	return s
+}
+
+func (g *SchemaGenerator) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		name, hasName := jsonFieldName(field)
+
+		if field.Anonymous && !hasName && indirectType(field.Type).Kind() == reflect.Struct {
+			g.addFields(s, indirectType(field.Type))
+			continue
+		}
+
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		fieldSchema := g.schemaFor(field.Type)
+
+		if applyValidateTag(g.valueSchema(fieldSchema), field.Type, field.Tag.Get("validate")) {
+			s.Required = append(s.Required, name)
+		}
+
+		s.Properties[name] = fieldSchema
+	}
+}
+
+// valueSchema returns the schema that describes the value inside a nullable wrapper
+func (g *SchemaGenerator) valueSchema(s *Schema) *Schema {
+	if len(s.AllOf) == 1 {
+		return s.AllOf[0]
+	}
+
+	if len(s.AnyOf) == 2 {
+		return s.AnyOf[0]
+	}
+
+	return s
+}
+
+func (g *SchemaGenerator) nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		if g.dialect == DialectOpenAPI30 {
+			return &Schema{AllOf: []*Schema{s}, Nullable: true}
+		}
+
+		return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+	}
+
+	if g.dialect == DialectOpenAPI30 {
+		s.Nullable = true
+		return s
+	}
+
+	switch existing := s.Type.(type) {
+	case string:
+		s.Type = []string{existing, "null"}
+	case []string:
+		s.Type = append(existing, "null")
+	}
+
+	return s
+}
+
+// optionalInnerType unwraps maybe.Maybe[T], maybe.Patch[T] and null.* types into the type of value they hold
+func optionalInnerType(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	if t.PkgPath() == maybePkgPath && (strings.HasPrefix(t.Name(), "Maybe[") || strings.HasPrefix(t.Name(), "Patch[")) {
+		if field, ok := t.FieldByName("value"); ok {
+			return field.Type, true
+		}
+	}
+
+	if t.PkgPath() == nullPkgPath && t.NumField() == 2 {
+		if _, ok := t.FieldByName("Valid"); ok {
+			return t.Field(0).Type, true
+		}
+	}
+
+	return nil, false
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "" {
+		return field.Name, false
+	}
+
+	return name, true
+}
+
+// applyValidateTag translates validator tags onto s, and reports if the field is required
+// tags after a dive apply to the items of an array or the values of a map
+func applyValidateTag(s *Schema, t reflect.Type, tag string) bool {
+	if tag == "" || tag == "-" {
+		return false
+	}
+
+	required := false
+	kind := schemaKind(t)
+
+	rules := strings.Split(tag, ",")
+
+	for i, rule := range rules {
+		// validator's or syntax can't be expressed as keywords on a single schema
+		if strings.Contains(rule, "|") {
+			continue
+		}
+
+		name, param, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "dive":
+			elemType := indirectType(t)
+			if inner, ok := optionalInnerType(elemType); ok {
+				elemType = inner
+			}
+
+			elemSchema := s.Items
+			if elemType.Kind() == reflect.Map {
+				elemSchema = s.AdditionalProperties
+			}
+
+			if elemSchema != nil && (elemType.Kind() == reflect.Slice || elemType.Kind() == reflect.Array || elemType.Kind() == reflect.Map) {
+				applyValidateTag(elemSchema, elemType.Elem(), strings.Join(rules[i+1:], ","))
+			}
+
+			return required
+		case "required":
+			required = true
+		case "min", "max", "len":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+
+			applyBound(s, kind, name, n)
+		case "gt", "gte", "lt", "lte":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil || kind != "number" {
+				continue
+			}
+
+			switch name {
+			case "gt":
+				s.ExclusiveMinimum = &n
+			case "gte":
+				s.Minimum = &n
+			case "lt":
+				s.ExclusiveMaximum = &n
+			case "lte":
+				s.Maximum = &n
+			}
+		case "oneof":
+			for _, option := range strings.Fields(param) {
+				if kind == "number" {
+					if n, err := strconv.ParseFloat(option, 64); err == nil {
+						s.Enum = append(s.Enum, n)
+						continue
+					}
+				}
+
+				s.Enum = append(s.Enum, option)
+			}
+		case "email":
+			s.Format = "email"
+		case "url", "uri", "http_url":
+			s.Format = "uri"
+		case "uuid", "uuid4", "uuid_rfc4122", "uuid4_rfc4122":
+			s.Format = "uuid"
+		case "ip", "ipv4":
+			s.Format = "ipv4"
+		case "ipv6":
+			s.Format = "ipv6"
+		case "hostname", "hostname_rfc1123":
+			s.Format = "hostname"
+		case "simpletext":
+			s.Pattern = simpleTextRegex.String()
+		case "alpha":
+			s.Pattern = "^[a-zA-Z]+$"
+		case "alphanum":
+			s.Pattern = "^[a-zA-Z0-9]+$"
+		case "numeric":
+			s.Pattern = `^[-+]?[0-9]+(?:\.[0-9]+)?$`
+		case "notblank":
+			s.Pattern = `\S`
+		case "isempty":
+			zero := 0
+			s.MaxLength = &zero
+		}
+	}
+
+	return required
+}
+
+func applyBound(s *Schema, kind string, name string, n float64) {
+	count := int(n)
+
+	switch kind {
+	case "string":
+		if name != "max" {
+			s.MinLength = &count
+		}
+		if name != "min" {
+			s.MaxLength = &count
+		}
+	case "array":
+		if name != "max" {
+			s.MinItems = &count
+		}
+		if name != "min" {
+			s.MaxItems = &count
+		}
+	case "number":
+		if name != "max" {
+			s.Minimum = &n
+		}
+		if name != "min" {
+			s.Maximum = &n
+		}
+	}
+}
+
+// schemaKind groups a go type into the categories validator uses to interpret min/max style params
+func schemaKind(t reflect.Type) string {
+	t = indirectType(t)
+
+	if inner, ok := optionalInnerType(t); ok {
+		t = indirectType(inner)
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	default:
+		return ""
+	}
+}
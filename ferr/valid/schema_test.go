@@ -0,0 +1,145 @@
+package valid
+
+import (
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/maybe"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type schemaTestAddress struct {
+	Street string `json:"street" validate:"required,max=100"`
+	City   string `json:"city"`
+}
+
+type schemaTestNode struct {
+	Name     string            `json:"name"`
+	Children []*schemaTestNode `json:"children"`
+}
+
+type schemaTestRequest struct {
+	Name      string                 `json:"name" validate:"required,min=2,max=50,simpletext"`
+	Age       int                    `json:"age" validate:"gte=18,lt=130"`
+	Status    string                 `json:"status" validate:"oneof=active disabled"`
+	Email     maybe.Maybe[string]    `json:"email" validate:"email"`
+	Score     maybe.Patch[float64]   `json:"score"`
+	Tags      []string               `json:"tags" validate:"max=5,dive,min=1"`
+	Labels    map[string]int         `json:"labels"`
+	Address   schemaTestAddress      `json:"address"`
+	Previous  *schemaTestAddress     `json:"previous"`
+	Tree      schemaTestNode         `json:"tree"`
+	CreatedAt time.Time              `json:"created_at"`
+	Inline    struct{ Flag bool }    `json:"inline"`
+	Metadata  map[string]interface{} `json:"-"`
+	internal  string
+}
+
+func TestGenerateJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	s := GenerateJSONSchema(&schemaTestRequest{})
+
+	assert.Equal(t, string(DialectJSONSchema), s.Schema)
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, []string{"name"}, s.Required)
+	assert.NotContains(t, s.Properties, "Metadata")
+	assert.NotContains(t, s.Properties, "internal")
+
+	name := s.Properties["name"]
+	assert.Equal(t, "string", name.Type)
+	assert.Equal(t, 2, *name.MinLength)
+	assert.Equal(t, 50, *name.MaxLength)
+	assert.Equal(t, simpleTextRegex.String(), name.Pattern)
+
+	age := s.Properties["age"]
+	assert.Equal(t, "integer", age.Type)
+	assert.Equal(t, 18.0, *age.Minimum)
+	assert.Equal(t, 130.0, *age.ExclusiveMaximum)
+
+	assert.Equal(t, []any{"active", "disabled"}, s.Properties["status"].Enum)
+
+	t.Run("maybe and patch fields are nullable", func(t *testing.T) {
+		assert.Equal(t, []string{"string", "null"}, s.Properties["email"].Type)
+		assert.Equal(t, "email", s.Properties["email"].Format)
+		assert.Equal(t, []string{"number", "null"}, s.Properties["score"].Type)
+	})
+
+	t.Run("slices and maps", func(t *testing.T) {
+		tags := s.Properties["tags"]
+		assert.Equal(t, "array", tags.Type)
+		assert.Equal(t, 5, *tags.MaxItems)
+
+		if assert.NotNil(t, tags.Items) {
+			assert.Equal(t, "string", tags.Items.Type)
+			assert.Equal(t, 1, *tags.Items.MinLength)
+		}
+
+		labels := s.Properties["labels"]
+		assert.Equal(t, "object", labels.Type)
+		assert.Equal(t, "integer", labels.AdditionalProperties.Type)
+	})
+
+	t.Run("nested structs", func(t *testing.T) {
+		assert.Equal(t, "#/$defs/schemaTestAddress", s.Properties["address"].Ref)
+
+		previous := s.Properties["previous"]
+		if assert.Len(t, previous.AnyOf, 2) {
+			assert.Equal(t, "#/$defs/schemaTestAddress", previous.AnyOf[0].Ref)
+			assert.Equal(t, "null", previous.AnyOf[1].Type)
+		}
+
+		address := s.Defs["schemaTestAddress"]
+		if assert.NotNil(t, address) {
+			assert.Equal(t, []string{"street"}, address.Required)
+			assert.Equal(t, 100, *address.Properties["street"].MaxLength)
+		}
+
+		inline := s.Properties["inline"]
+		assert.Empty(t, inline.Ref)
+		assert.Equal(t, "boolean", inline.Properties["Flag"].Type)
+
+		assert.Equal(t, "date-time", s.Properties["created_at"].Format)
+	})
+
+	t.Run("recursive types", func(t *testing.T) {
+		node := s.Defs["schemaTestNode"]
+		if assert.NotNil(t, node) {
+			children := node.Properties["children"]
+			assert.Equal(t, "array", children.Type)
+
+			if assert.Len(t, children.Items.AnyOf, 2) {
+				assert.Equal(t, "#/$defs/schemaTestNode", children.Items.AnyOf[0].Ref)
+			}
+		}
+	})
+}
+
+func TestSchemaGeneratorOpenAPI(t *testing.T) {
+	t.Parallel()
+
+	g := NewSchemaGenerator(DialectOpenAPI30)
+
+	root := g.Add(schemaTestRequest{})
+	assert.Equal(t, "#/components/schemas/schemaTestRequest", root.Ref)
+
+	components := g.Components()
+	assert.Contains(t, components, "schemaTestAddress")
+	assert.Contains(t, components, "schemaTestNode")
+
+	request := components["schemaTestRequest"]
+	if assert.NotNil(t, request) {
+		assert.Equal(t, "string", request.Properties["email"].Type)
+		assert.True(t, request.Properties["email"].Nullable)
+
+		previous := request.Properties["previous"]
+		assert.True(t, previous.Nullable)
+
+		if assert.Len(t, previous.AllOf, 1) {
+			assert.Equal(t, "#/components/schemas/schemaTestAddress", previous.AllOf[0].Ref)
+		}
+	}
+
+	// adding a type again reuses its component
+	assert.Equal(t, root.Ref, g.Add(&schemaTestRequest{}).Ref)
+	assert.Len(t, g.Components(), len(components))
+}
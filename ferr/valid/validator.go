@@ -2,15 +2,17 @@ package valid
 
 import (
 	"context"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
 	"github.com/datomar-labs-inc/FCT_Helpers_Go/maybe"
-	"github.com/go-playground/locales/en"
 	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"github.com/go-playground/validator/v10/non-standard/validators"
 	en2 "github.com/go-playground/validator/v10/translations/en"
+	"github.com/google/uuid"
 	"github.com/volatiletech/null/v8"
 	"reflect"
 	"regexp"
+	"time"
 )
 
 var validate *validator.Validate
@@ -19,11 +21,8 @@ var UniversalTranslator ut.Translator
 var simpleTextRegex *regexp.Regexp
 
 func init() {
-	ent := en.New()
-
-	var uni = ut.New(ent, ent)
-
-	trans, _ := uni.GetTranslator("en")
+	// translations are registered with the translator of ferr, so ferr.Infer translates errors of this validator
+	trans := ferr.ValidationTranslator()
 
 	UniversalTranslator = trans
 
@@ -44,12 +43,38 @@ func init() {
 	// register all sql.Null* types to use the ValidateValuer CustomTypeFunc
 	validate.RegisterCustomTypeFunc(ValidateNullString, null.String{}, &null.String{})
 
-	// TODO register all maybe.Maybe types to use the ValidateValuer CustomTypeFunc
-	validate.RegisterCustomTypeFunc(maybe.ValidateValuer, maybe.Maybe[string]{}, &maybe.Maybe[int]{})
+	// register maybe.Maybe and maybe.Patch of the builtin types to use the ValidateValuer CustomTypeFunc
+	RegisterMaybeType[string]()
+	RegisterMaybeType[bool]()
+	RegisterMaybeType[int]()
+	RegisterMaybeType[int8]()
+	RegisterMaybeType[int16]()
+	RegisterMaybeType[int32]()
+	RegisterMaybeType[int64]()
+	RegisterMaybeType[uint]()
+	RegisterMaybeType[uint8]()
+	RegisterMaybeType[uint16]()
+	RegisterMaybeType[uint32]()
+	RegisterMaybeType[uint64]()
+	RegisterMaybeType[float32]()
+	RegisterMaybeType[float64]()
+	RegisterMaybeType[[]byte]()
+	RegisterMaybeType[[]string]()
+	RegisterMaybeType[time.Time]()
+	RegisterMaybeType[uuid.UUID]()
 
 	_ = validate.RegisterValidation("notblank", validators.NotBlank)
 
 	_ = en2.RegisterDefaultTranslations(validate, trans)
+
+	registerMaybeRules(trans)
+}
+
+// RegisterMaybeType makes validate tags run on maybe.Maybe[T] and maybe.Patch[T] fields
+// validator skips the tags of struct fields that have no custom type func, so this is needed for every T that isn't
+// registered above. Call it from an init function, registering isn't safe while structs are being validated
+func RegisterMaybeType[T any]() {
+	validate.RegisterCustomTypeFunc(maybe.ValidateValuer, maybe.Maybe[T]{}, maybe.Patch[T]{})
 }
 
 func ValidateStruct(ctx context.Context, s any) error {
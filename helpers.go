@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
-	"github.com/friendsofgo/errors"
 	"math/rand"
 	"reflect"
+	"sync"
 	"time"
 	"unsafe"
 )
@@ -22,7 +22,8 @@ func ToPtr[T any](v T) *T {
 
 func StringSliceFromAnySlice[St any](slice []St) (out []string) {
 	for _, st := range slice {
-		out = append(out, fmt.Sprintf("%s", st))
+		// converted to any, since vet can't tell whether a type parameter formats with %s
+		out = append(out, fmt.Sprintf("%s", any(st)))
 	}
 
 	return
@@ -197,6 +198,8 @@ func IsNil(i any) bool {
 }
 
 // JSONConvert will convert anything into a type via json serialization
+// fields that don't match are silently dropped, and the bool is always true when err is nil
+// use JSONConvertStrict to find out which fields were dropped, or MapStruct for struct to struct conversions
 func JSONConvert[R any](input any) (R, bool, error) {
 	var output R
 
@@ -219,7 +222,10 @@ func (a *Array[T]) Push(item T) {
 	*a = append(*a, item)
 }
 
-var src = rand.NewSource(time.Now().UnixNano())
+var (
+	srcMu sync.Mutex
+	src   = rand.NewSource(time.Now().UnixNano())
+)
 
 const letterBytes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
 const (
@@ -228,7 +234,12 @@ const (
 	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
 )
 
+// RandString generates a random string of n letters
+// it is predictable, and must not be used for secrets or codes people could guess, use SecureRandString for those
 func RandString(n int) string {
+	srcMu.Lock()
+	defer srcMu.Unlock()
+
 	b := make([]byte, n)
 	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
 		if remain == 0 {
@@ -278,15 +289,20 @@ func FindPtr[T any](slice []*T, predicate func(i *T, idx int) bool) *T {
 	return nil
 }
 
+// FindSliceDiff compares a to b, items only in b are added, and items only in a are removed
+// items keep their original order, and duplicates are preserved
 func FindSliceDiff[T comparable](a []T, b []T) (added []T, removed []T) {
+	inA := NewSet(a...)
+	inB := NewSet(b...)
+
 	for _, t := range a {
-		if !SliceContains(b, t) {
+		if !inB.Contains(t) {
 			removed = append(removed, t)
 		}
 	}
 
 	for _, t := range b {
-		if !SliceContains(a, t) {
+		if !inA.Contains(t) {
 			added = append(added, t)
 		}
 	}
@@ -294,31 +310,19 @@ func FindSliceDiff[T comparable](a []T, b []T) (added []T, removed []T) {
 	return
 }
 
+// WaitForSomethingToHappen polls checker until it returns true, or timeoutSeconds have passed
+//
+// Deprecated: use Poll, which supports backoff and attempt limits
 func WaitForSomethingToHappen(ctx context.Context, timeoutSeconds int, checker func() (bool, error)) error {
-	startTime := time.Now()
-	for {
-		deadline, deadlineExists := ctx.Deadline()
-		if deadlineExists {
-			if time.Now().After(deadline) {
-				return errors.New("timeout")
-			}
-		}
-
-		if time.Now().After(startTime.Add(time.Second * time.Duration(timeoutSeconds))) {
-			return errors.New("timeout")
-		}
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSeconds)*time.Second)
+	defer cancel()
 
-		exists, err := checker()
-		if err != nil {
-			return err
-		}
-
-		if exists {
-			return nil
-		}
-	}
+	return Poll(ctx, nil, func(_ context.Context) (bool, error) {
+		return checker()
+	})
 }
 
+// RandFromSlice picks a random item from slice, it is predictable, use SecureRandFromSlice if that matters
 func RandFromSlice[T any](slice []T) T {
 	return slice[rand.Intn(len(slice))]
 }
@@ -88,3 +88,9 @@ func AttachToContext(parent context.Context, logger *LogWrapper) context.Context
 
 	return context.WithValue(parent, ContextKey, logger)
 }
+
+// GetDetached returns a named logger that isn't attached to a context, for code that runs outside of a request
+// it uses zap's global logger, which discards everything until it is replaced with zap.ReplaceGlobals
+func GetDetached(name string) *LogWrapper {
+	return zap.L().Named(name)
+}
@@ -0,0 +1,187 @@
+package maybe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OrElse returns the value if set, otherwise the result of calling orElse
+// use this over Or when the default is expensive to compute
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m Maybe[T]) OrElse(orElse func() T) T {
+	if m.hasValue {
+		return m.value
+	}
+
+	return orElse()
+}
+
+// Filter keeps the value only if predicate returns true for it
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m Maybe[T]) Filter(predicate func(val T) bool) Maybe[T] {
+	if m.hasValue && predicate(m.value) {
+		return m
+	}
+
+	return Empty[T]()
+}
+
+// ToPtr returns a pointer to a copy of the value, or nil if there is no value
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m Maybe[T]) ToPtr() *T {
+	if !m.hasValue {
+		return nil
+	}
+
+	v := m.value
+
+	return &v
+}
+
+// FlatMap is like Map, but mapFunc can decide that there is no result
+func FlatMap[St any, Mt any](from Maybe[St], mapFunc func(value St) Maybe[Mt]) Maybe[Mt] {
+	if v, ok := from.Value(); ok {
+		return mapFunc(v)
+	}
+
+	return Empty[Mt]()
+}
+
+// FromPtr creates a Maybe holding a copy of the pointed to value, a nil pointer creates an empty Maybe
+func FromPtr[T any](ptr *T) Maybe[T] {
+	if ptr == nil {
+		return Empty[T]()
+	}
+
+	return WithValue(*ptr)
+}
+
+// FromZero creates a Maybe that is empty if val is the zero value of T
+func FromZero[T comparable](val T) Maybe[T] {
+	var zero T
+
+	if val == zero {
+		return Empty[T]()
+	}
+
+	return WithValue(val)
+}
+
+// Pair is the value of a Zip2
+type Pair[A any, B any] struct {
+	A A
+	B B
+}
+
+// Triple is the value of a Zip3
+type Triple[A any, B any, C any] struct {
+	A A
+	B B
+	C C
+}
+
+// Zip2 combines two maybies, the result only has a value if both of them do
+func Zip2[A any, B any](a Maybe[A], b Maybe[B]) Maybe[Pair[A, B]] {
+	if !a.hasValue || !b.hasValue {
+		return Empty[Pair[A, B]]()
+	}
+
+	return WithValue(Pair[A, B]{A: a.value, B: b.value})
+}
+
+// Zip3 combines three maybies, the result only has a value if all of them do
+func Zip3[A any, B any, C any](a Maybe[A], b Maybe[B], c Maybe[C]) Maybe[Triple[A, B, C]] {
+	if !a.hasValue || !b.hasValue || !c.hasValue {
+		return Empty[Triple[A, B, C]]()
+	}
+
+	return WithValue(Triple[A, B, C]{A: a.value, B: b.value, C: c.value})
+}
+
+// Collect turns a slice of maybies into a maybe of a slice, the result only has a value if every item does
+func Collect[T any](maybies []Maybe[T]) Maybe[[]T] {
+	values := make([]T, 0, len(maybies))
+
+	for _, m := range maybies {
+		if !m.hasValue {
+			return Empty[[]T]()
+		}
+
+		values = append(values, m.value)
+	}
+
+	return WithValue(values)
+}
+
+// Values returns the values of every maybe that is set, skipping the empty ones
+func Values[T any](maybies []Maybe[T]) []T {
+	var values []T
+
+	for _, m := range maybies {
+		if m.hasValue {
+			values = append(values, m.value)
+		}
+	}
+
+	return values
+}
+
+// First returns the first maybe that has a value, or an empty maybe if none do
+func First[T any](maybies ...Maybe[T]) Maybe[T] {
+	for _, m := range maybies {
+		if m.hasValue {
+			return m
+		}
+	}
+
+	return Empty[T]()
+}
+
+// SetCountError is returned by ExactlyOneSet when zero, or more than one, of the maybies are set
+type SetCountError struct {
+	// SetIndexes are the indexes of every maybe that was set
+	SetIndexes []int
+}
+
+// OffendingIndex is the index of the second set maybe, or -1 if none were set
+func (e *SetCountError) OffendingIndex() int {
+	if len(e.SetIndexes) < 2 {
+		return -1
+	}
+
+	return e.SetIndexes[1]
+}
+
+func (e *SetCountError) Error() string {
+	if len(e.SetIndexes) == 0 {
+		return "expected exactly one value to be set, but none were"
+	}
+
+	indexes := make([]string, len(e.SetIndexes))
+	for i, idx := range e.SetIndexes {
+		indexes[i] = fmt.Sprintf("%d", idx)
+	}
+
+	return fmt.Sprintf("expected exactly one value to be set, but values at indexes %s were set", strings.Join(indexes, ", "))
+}
+
+// ExactlyOneSet returns the index of the only maybe that is set
+// if none, or more than one are set, a *SetCountError is returned
+func ExactlyOneSet(maybies ...Ish) (int, error) {
+	var setIndexes []int
+
+	for idx, m := range maybies {
+		if m.HasValue() {
+			setIndexes = append(setIndexes, idx)
+		}
+	}
+
+	if len(setIndexes) != 1 {
+		return -1, &SetCountError{SetIndexes: setIndexes}
+	}
+
+	return setIndexes[0], nil
+}
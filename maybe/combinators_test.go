@@ -0,0 +1,220 @@
+package maybe
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestMaybe_OrElse(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	orElse := func() int {
+		calls++
+		return 2
+	}
+
+	assert.Equal(t, 1, WithValue(1).OrElse(orElse))
+	assert.Equal(t, 0, calls)
+
+	assert.Equal(t, 2, Empty[int]().OrElse(orElse))
+	assert.Equal(t, 1, calls)
+}
+
+func TestMaybe_Filter(t *testing.T) {
+	t.Parallel()
+
+	even := func(v int) bool { return v%2 == 0 }
+
+	assert.Equal(t, WithValue(2), WithValue(2).Filter(even))
+	assert.Equal(t, Empty[int](), WithValue(3).Filter(even))
+	assert.Equal(t, Empty[int](), Empty[int]().Filter(even))
+}
+
+func TestMaybe_ToPtr(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, Empty[int]().ToPtr())
+
+	m := WithValue(1)
+
+	ptr := m.ToPtr()
+	if assert.NotNil(t, ptr) {
+		*ptr = 2
+		assert.Equal(t, 1, m.Or(0))
+	}
+}
+
+func TestFlatMap(t *testing.T) {
+	t.Parallel()
+
+	parse := func(s string) Maybe[int] {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return Empty[int]()
+		}
+
+		return WithValue(n)
+	}
+
+	assert.Equal(t, WithValue(12), FlatMap(WithValue("12"), parse))
+	assert.Equal(t, Empty[int](), FlatMap(WithValue("twelve"), parse))
+	assert.Equal(t, Empty[int](), FlatMap(Empty[string](), parse))
+}
+
+func TestFromPtr(t *testing.T) {
+	t.Parallel()
+
+	v := 1
+
+	m := FromPtr(&v)
+	v = 2
+
+	assert.Equal(t, WithValue(1), m)
+	assert.Equal(t, Empty[int](), FromPtr[int](nil))
+}
+
+func TestFromZero(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, WithValue("a"), FromZero("a"))
+	assert.Equal(t, Empty[string](), FromZero(""))
+	assert.Equal(t, Empty[int](), FromZero(0))
+}
+
+func TestZip(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, WithValue(Pair[int, string]{A: 1, B: "b"}), Zip2(WithValue(1), WithValue("b")))
+	assert.Equal(t, Empty[Pair[int, string]](), Zip2(WithValue(1), Empty[string]()))
+	assert.Equal(t, Empty[Pair[int, string]](), Zip2(Empty[int](), WithValue("b")))
+
+	assert.Equal(t, WithValue(Triple[int, string, bool]{A: 1, B: "b", C: true}), Zip3(WithValue(1), WithValue("b"), WithValue(true)))
+	assert.Equal(t, Empty[Triple[int, string, bool]](), Zip3(WithValue(1), WithValue("b"), Empty[bool]()))
+}
+
+func TestCollect(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, WithValue([]int{1, 2}), Collect([]Maybe[int]{WithValue(1), WithValue(2)}))
+	assert.Equal(t, Empty[[]int](), Collect([]Maybe[int]{WithValue(1), Empty[int]()}))
+	assert.Equal(t, WithValue([]int{}), Collect[int](nil))
+}
+
+func TestValues(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []int{1, 3}, Values([]Maybe[int]{WithValue(1), Empty[int](), WithValue(3)}))
+	assert.Empty(t, Values([]Maybe[int]{Empty[int]()}))
+	assert.Empty(t, Values[int](nil))
+}
+
+func TestFirst(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, WithValue(2), First(Empty[int](), WithValue(2), WithValue(3)))
+	assert.Equal(t, Empty[int](), First(Empty[int](), Empty[int]()))
+	assert.Equal(t, Empty[int](), First[int]())
+}
+
+func TestExactlyOneSet(t *testing.T) {
+	t.Parallel()
+
+	idx, err := ExactlyOneSet(Empty[int](), WithValue("b"), PatchNull[int]())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, idx)
+
+	_, err = ExactlyOneSet(Empty[int](), PatchNull[int]())
+
+	var countErr *SetCountError
+	if assert.ErrorAs(t, err, &countErr) {
+		assert.Empty(t, countErr.SetIndexes)
+		assert.Equal(t, -1, countErr.OffendingIndex())
+		assert.Equal(t, "expected exactly one value to be set, but none were", countErr.Error())
+	}
+
+	_, err = ExactlyOneSet(WithValue(1), Empty[int](), PatchWithValue(3))
+	if assert.ErrorAs(t, err, &countErr) {
+		assert.Equal(t, []int{0, 2}, countErr.SetIndexes)
+		assert.Equal(t, 2, countErr.OffendingIndex())
+		assert.Equal(t, "expected exactly one value to be set, but values at indexes 0, 2 were set", countErr.Error())
+	}
+}
+
+func TestResult(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	ok := Ok(1)
+	failed := Err[int](errBoom)
+
+	assert.True(t, ok.IsOk())
+	assert.NoError(t, ok.Err())
+	assert.False(t, failed.IsOk())
+	assert.Equal(t, errBoom, failed.Err())
+
+	v, err := ok.Get()
+	assert.Equal(t, 1, v)
+	assert.NoError(t, err)
+
+	_, err = failed.Get()
+	assert.Equal(t, errBoom, err)
+
+	assert.Equal(t, 1, ok.Or(2))
+	assert.Equal(t, 2, failed.Or(2))
+
+	assert.Equal(t, WithValue(1), ok.Maybe())
+	assert.Equal(t, Empty[int](), failed.Maybe())
+
+	assert.Equal(t, ok, Try(1, nil))
+	assert.Equal(t, failed, Try(1, errBoom))
+
+	assert.Equal(t, ok, OkOr(WithValue(1), errBoom))
+	assert.Equal(t, failed, OkOr(Empty[int](), errBoom))
+
+	// a nil error still gives a failed result
+	assert.False(t, Err[int](nil).IsOk())
+	assert.ErrorIs(t, Err[int](nil).Err(), ErrNoValue)
+	assert.ErrorIs(t, OkOr(Empty[int](), nil).Err(), ErrNoValue)
+	assert.True(t, OkOr(WithValue(1), nil).IsOk())
+}
+
+func TestMapResult(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	assert.Equal(t, Ok("1"), MapResult(Ok(1), strconv.Itoa))
+	assert.Equal(t, Err[string](errBoom), MapResult(Err[int](errBoom), strconv.Itoa))
+}
+
+func TestAndThen(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	assert.Equal(t, Ok(12), AndThen(Ok("12"), strconv.Atoi))
+	assert.False(t, AndThen(Ok("twelve"), strconv.Atoi).IsOk())
+	assert.Equal(t, Err[int](errBoom), AndThen(Err[string](errBoom), strconv.Atoi))
+}
+
+func TestCollectResults(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	values, err := CollectResults([]Result[int]{Ok(1), Ok(2)})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, values)
+
+	values, err = CollectResults([]Result[int]{Ok(1), Err[int](errBoom), Err[int](errors.New("later"))})
+	assert.Equal(t, errBoom, err)
+	assert.Nil(t, values)
+
+	values, err = CollectResults[int](nil)
+	assert.NoError(t, err)
+	assert.Empty(t, values)
+}
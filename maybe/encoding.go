@@ -0,0 +1,155 @@
+package maybe
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strconv"
+)
+
+// IsZero reports if the maybe is empty
+// encoding/json (omitzero, go 1.24+) and yaml.v3 (omitempty) use this to omit empty maybies
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m Maybe[T]) IsZero() bool {
+	return !m.hasValue
+}
+
+// MarshalText implements encoding.TextMarshaler, an empty maybe is written as an empty string
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m Maybe[T]) MarshalText() ([]byte, error) {
+	if !m.hasValue {
+		return []byte{}, nil
+	}
+
+	if marshaler, ok := any(m.value).(encoding.TextMarshaler); ok {
+		return marshaler.MarshalText()
+	}
+
+	v := reflect.ValueOf(m.value)
+
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return []byte(fmt.Sprint(m.value)), nil
+	default:
+		return json.Marshal(m.value)
+	}
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, which lets a maybe be used in query strings
+// empty text results in an empty maybe
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m *Maybe[T]) UnmarshalText(text []byte) error {
+	if m == nil {
+		return nil
+	}
+
+	if len(text) == 0 {
+		*m = Empty[T]()
+		return nil
+	}
+
+	var value T
+
+	if unmarshaler, ok := any(&value).(encoding.TextUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalText(text); err != nil {
+			return err
+		}
+
+		*m = WithValue(value)
+
+		return nil
+	}
+
+	if err := parseText(reflect.ValueOf(&value).Elem(), string(text)); err != nil {
+		return err
+	}
+
+	*m = WithValue(value)
+
+	return nil
+}
+
+// MarshalYAML implements yaml.Marshaler, an empty maybe is written as null
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m Maybe[T]) MarshalYAML() (any, error) {
+	if !m.hasValue {
+		return nil, nil
+	}
+
+	return m.value, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler, an explicit null results in an empty maybe
+// yaml.v3 doesn't call unmarshalers for null nodes though, so a null leaves an already set maybe untouched
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m *Maybe[T]) UnmarshalYAML(node *yaml.Node) error {
+	if m == nil {
+		return nil
+	}
+
+	if node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" {
+		*m = Empty[T]()
+		return nil
+	}
+
+	var value T
+
+	if err := node.Decode(&value); err != nil {
+		return err
+	}
+
+	*m = WithValue(value)
+
+	return nil
+}
+
+func parseText(dest reflect.Value, text string) error {
+	switch dest.Kind() {
+	case reflect.String:
+		dest.SetString(text)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+
+		dest.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, dest.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		dest.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(text, 10, dest.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		dest.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(text, dest.Type().Bits())
+		if err != nil {
+			return err
+		}
+
+		dest.SetFloat(n)
+	default:
+		// anything more complicated than a scalar is expected to be json
+		return json.Unmarshal([]byte(text), dest.Addr().Interface())
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package maybe
+
+import (
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"gopkg.in/yaml.v3"
+	"testing"
+	"time"
+)
+
+type textTestPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func textRoundTrip[T any](t *testing.T, m Maybe[T]) {
+	t.Helper()
+
+	text, err := m.MarshalText()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var decoded Maybe[T]
+
+	assert.NoError(t, decoded.UnmarshalText(text))
+	assert.Equal(t, m, decoded)
+}
+
+func TestMaybe_Text(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2022, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	textRoundTrip(t, WithValue("hello"))
+	textRoundTrip(t, WithValue(-12))
+	textRoundTrip(t, WithValue(uint16(12)))
+	textRoundTrip(t, WithValue(true))
+	textRoundTrip(t, WithValue(1.5))
+	textRoundTrip(t, WithValue(at))
+	textRoundTrip(t, WithValue(textTestPoint{X: 1, Y: 2}))
+	textRoundTrip(t, Empty[string]())
+	textRoundTrip(t, Empty[int]())
+
+	text, err := WithValue(at).MarshalText()
+	assert.NoError(t, err)
+	assert.Equal(t, "2022-03-04T05:06:07Z", string(text))
+
+	text, err = Empty[int]().MarshalText()
+	assert.NoError(t, err)
+	assert.Empty(t, text)
+
+	var m Maybe[int]
+	assert.Error(t, m.UnmarshalText([]byte("twelve")))
+	assert.False(t, m.HasValue())
+
+	var small Maybe[int8]
+	assert.Error(t, small.UnmarshalText([]byte("300")))
+	assert.NoError(t, (*Maybe[int])(nil).UnmarshalText([]byte("1")))
+}
+
+type yamlTestDocument struct {
+	Name     Maybe[string]        `yaml:"name"`
+	Age      Maybe[int]           `yaml:"age"`
+	Nickname Maybe[string]        `yaml:"nickname,omitempty"`
+	Point    Maybe[textTestPoint] `yaml:"point"`
+}
+
+func TestMaybe_YAML(t *testing.T) {
+	t.Parallel()
+
+	doc := yamlTestDocument{
+		Name:  WithValue("Jim"),
+		Point: WithValue(textTestPoint{X: 1, Y: 2}),
+	}
+
+	out, err := yaml.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, string(out), "age: null")
+	assert.NotContains(t, string(out), "nickname")
+
+	var decoded yamlTestDocument
+
+	assert.NoError(t, yaml.Unmarshal(out, &decoded))
+	assert.Equal(t, doc, decoded)
+
+	decoded = yamlTestDocument{}
+
+	assert.NoError(t, yaml.Unmarshal([]byte("age: ~\nname: \"\"\n"), &decoded))
+	assert.False(t, decoded.Age.HasValue())
+	assert.Equal(t, WithValue(""), decoded.Name)
+
+	assert.Error(t, yaml.Unmarshal([]byte("age: twelve\n"), &decoded))
+}
+
+func TestProtoWrappers(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, WithValue("a"), FromStringValue(ToStringValue(WithValue("a"))))
+	assert.Equal(t, WithValue(""), FromStringValue(ToStringValue(WithValue(""))))
+	assert.Equal(t, WithValue(false), FromBoolValue(ToBoolValue(WithValue(false))))
+	assert.Equal(t, WithValue(int32(-3)), FromInt32Value(ToInt32Value(WithValue(int32(-3)))))
+	assert.Equal(t, WithValue(int64(4)), FromInt64Value(ToInt64Value(WithValue(int64(4)))))
+	assert.Equal(t, WithValue(uint32(5)), FromUInt32Value(ToUInt32Value(WithValue(uint32(5)))))
+	assert.Equal(t, WithValue(uint64(6)), FromUInt64Value(ToUInt64Value(WithValue(uint64(6)))))
+	assert.Equal(t, WithValue(float32(1.5)), FromFloatValue(ToFloatValue(WithValue(float32(1.5)))))
+	assert.Equal(t, WithValue(2.5), FromDoubleValue(ToDoubleValue(WithValue(2.5))))
+	assert.Equal(t, WithValue([]byte("b")), FromBytesValue(ToBytesValue(WithValue([]byte("b")))))
+
+	assert.Nil(t, ToStringValue(Empty[string]()))
+	assert.Nil(t, ToInt64Value(Empty[int64]()))
+	assert.Nil(t, ToBytesValue(Empty[[]byte]()))
+
+	assert.Equal(t, Empty[string](), FromStringValue(nil))
+	assert.Equal(t, Empty[bool](), FromBoolValue(nil))
+	assert.Equal(t, Empty[float64](), FromDoubleValue(nil))
+	assert.Equal(t, Empty[int32](), FromProto[int32]((*wrapperspb.Int32Value)(nil)))
+}
@@ -5,6 +5,9 @@ import (
 	"reflect"
 )
 
+// Maybe holds an optional value
+// it implements sql.Scanner, but not driver.Valuer, since Value already returns (T, bool). Wrap it with AsColumn
+// to pass it to Exec or QueryRow
 type Maybe[T any] struct {
 	hasValue bool
 	value    T
@@ -33,8 +36,13 @@ func (m Maybe[T]) Value() (T, bool) {
 	return m.value, true
 }
 
+// validatorValue returns a typed nil pointer when empty, so that validations registered to run on nil values still run
 func (m Maybe[T]) validatorValue() (any, bool) {
-	return m.Value()
+	if !m.hasValue {
+		return (*T)(nil), false
+	}
+
+	return m.value, true
 }
 
 // UnmarshalJSON
@@ -71,6 +79,20 @@ func (m Maybe[T]) MarshalJSON() ([]byte, error) {
 	return []byte("null"), nil
 }
 
+// Set gives the maybe a value
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m *Maybe[T]) Set(val T) {
+	*m = WithValue(val)
+}
+
+// Clear removes the value from the maybe
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m *Maybe[T]) Clear() {
+	*m = Empty[T]()
+}
+
 //goland:noinspection GoMixedReceiverTypes
 func (m Maybe[T]) If(ifFunc func(val T)) {
 	if m.hasValue {
@@ -146,9 +168,8 @@ func ValidateValuer(field reflect.Value) any {
 	// Try to cast as a maybe
 	if field.Kind() == reflect.Struct {
 		if castedMaybe, ok := field.Interface().(maybeValidatorValue); ok {
-			if val, ok := castedMaybe.validatorValue(); ok {
-				return val
-			}
+			val, _ := castedMaybe.validatorValue()
+			return val
 		}
 
 		return nil
@@ -0,0 +1,375 @@
+package maybe
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// PatchState describes which of the three states a Patch is in
+type PatchState int
+
+const (
+	// Absent means the field was not present in the input, and should be left alone
+	Absent PatchState = iota
+
+	// Null means the field was explicitly set to null, and should be cleared
+	Null
+
+	// Set means the field was given a value
+	Set
+)
+
+// Patch is a tri-state version of Maybe, it can tell the difference between a missing key and an explicit null
+// the zero value is Absent, so a Patch field that was not in a JSON document stays Absent after unmarshalling
+// the distinction is lost when a Patch is marshalled on its own, use MarshalJSONOmitAbsent to keep it for structs
+type Patch[T any] struct {
+	state PatchState
+	value T
+}
+
+// patchApplier is implemented by Patch so that ApplyPatch can inspect any Patch[T] through reflection
+type patchApplier interface {
+	patchState() (PatchState, any)
+}
+
+// valueSetter is implemented by Maybe and Patch so that their value can be set through reflection
+type valueSetter interface {
+	setAny(value any, hasValue bool) bool
+}
+
+func PatchWithValue[T any](val T) Patch[T] {
+	return Patch[T]{
+		state: Set,
+		value: val,
+	}
+}
+
+func PatchNull[T any]() Patch[T] {
+	return Patch[T]{
+		state: Null,
+	}
+}
+
+func PatchAbsent[T any]() Patch[T] {
+	return Patch[T]{}
+}
+
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) State() PatchState {
+	return p.state
+}
+
+// HasValue reports if the patch has a value, explicit nulls do not count
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) HasValue() bool {
+	return p.state == Set
+}
+
+// Set gives the patch a value
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p *Patch[T]) Set(val T) {
+	*p = PatchWithValue(val)
+}
+
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) IsNull() bool {
+	return p.state == Null
+}
+
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) IsAbsent() bool {
+	return p.state == Absent
+}
+
+// IsPresent reports if the field appeared in the input at all, either as a value or as null
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) IsPresent() bool {
+	return p.state != Absent
+}
+
+// IsZero reports if the patch is Absent
+// encoding/json uses this to omit absent fields tagged with omitzero (go 1.24+)
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) IsZero() bool {
+	return p.state == Absent
+}
+
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) Value() (T, bool) {
+	return p.value, p.state == Set
+}
+
+// Maybe converts the patch into a Maybe, both Null and Absent become an empty Maybe
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) Maybe() Maybe[T] {
+	if p.state == Set {
+		return WithValue(p.value)
+	}
+
+	return Empty[T]()
+}
+
+// Ptr returns the value to assign to a pointer field, and whether the field should be changed at all
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) Ptr() (*T, bool) {
+	switch p.state {
+	case Set:
+		v := p.value
+		return &v, true
+	case Null:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+// ApplyTo updates target if the patch is present, an explicit null sets target to the zero value of T
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) ApplyTo(target *T) {
+	if target == nil {
+		return
+	}
+
+	switch p.state {
+	case Set:
+		*target = p.value
+	case Null:
+		var zero T
+		*target = zero
+	}
+}
+
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) validatorValue() (any, bool) {
+	if p.state != Set {
+		return (*T)(nil), false
+	}
+
+	return p.value, true
+}
+
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) patchState() (PatchState, any) {
+	return p.state, p.value
+}
+
+// UnmarshalJSON is only called by encoding/json when the key is present, so the patch becomes either Null or Set
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p *Patch[T]) UnmarshalJSON(bytes []byte) error {
+	if p == nil {
+		return nil
+	}
+
+	if string(bytes) == "null" {
+		var zero T
+
+		p.state = Null
+		p.value = zero
+
+		return nil
+	}
+
+	var value T
+
+	err := json.Unmarshal(bytes, &value)
+	if err != nil {
+		return err
+	}
+
+	p.value = value
+	p.state = Set
+
+	return nil
+}
+
+// MarshalJSON writes null for both Null and Absent patches, since a marshaller can't omit its own key
+// marshal the struct holding the patch with MarshalJSONOmitAbsent to leave absent fields out
+//
+//goland:noinspection GoMixedReceiverTypes
+func (p Patch[T]) MarshalJSON() ([]byte, error) {
+	if p.state == Set {
+		return json.Marshal(p.value)
+	}
+
+	return []byte("null"), nil
+}
+
+// MarshalJSONOmitAbsent marshals v like json.Marshal, leaving out the Absent Patch fields of v and its embedded structs
+// so unmarshalling the result gives the same patch states back. The keys of structs are written in sorted order
+func MarshalJSONOmitAbsent(v any) ([]byte, error) {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return json.Marshal(v)
+	}
+
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+
+	if err := json.Unmarshal(jsonBytes, &fields); err != nil {
+		return nil, err
+	}
+
+	removeAbsentFields(value, fields)
+
+	return json.Marshal(fields)
+}
+
+// removeAbsentFields deletes the keys of the Absent Patch fields of a struct from its marshalled fields
+func removeAbsentFields(value reflect.Value, fields map[string]json.RawMessage) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+		if field.Anonymous && name == "" {
+			embedded := reflect.Indirect(value.Field(i))
+			if embedded.Kind() == reflect.Struct {
+				removeAbsentFields(embedded, fields)
+			}
+
+			continue
+		}
+
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if applier, ok := value.Field(i).Interface().(patchApplier); ok {
+			if state, _ := applier.patchState(); state == Absent {
+				delete(fields, name)
+			}
+		}
+	}
+}
+
+//goland:noinspection GoMixedReceiverTypes
+func (p *Patch[T]) setAny(value any, hasValue bool) bool {
+	if !hasValue {
+		*p = PatchNull[T]()
+		return true
+	}
+
+	if v, ok := value.(T); ok {
+		*p = PatchWithValue(v)
+		return true
+	}
+
+	return false
+}
+
+//goland:noinspection GoMixedReceiverTypes
+func (m *Maybe[T]) setAny(value any, hasValue bool) bool {
+	if !hasValue {
+		*m = Empty[T]()
+		return true
+	}
+
+	if v, ok := value.(T); ok {
+		*m = WithValue(v)
+		return true
+	}
+
+	return false
+}
+
+// ApplyPatch copies every present Patch field of patch onto the field with the same name in target
+// a field can be mapped to a differently named target field with a `patch:"TargetField"` tag, or skipped with `patch:"-"`
+// Set values are assigned to target fields of type T, *T, Maybe[T] or Patch[T]
+// explicit nulls reset the target field to its zero value, Absent fields are left alone
+// target must be a pointer to a struct, and patch must be a struct or a pointer to one
+func ApplyPatch(target any, patch any) error {
+	targetValue := reflect.ValueOf(target)
+	if targetValue.Kind() != reflect.Ptr || targetValue.IsNil() || targetValue.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("patch target must be a non nil pointer to a struct, got %T", target)
+	}
+
+	patchValue := reflect.Indirect(reflect.ValueOf(patch))
+	if patchValue.Kind() != reflect.Struct {
+		return fmt.Errorf("patch must be a struct, got %T", patch)
+	}
+
+	targetValue = targetValue.Elem()
+
+	for i := 0; i < patchValue.NumField(); i++ {
+		field := patchValue.Type().Field(i)
+
+		if !field.IsExported() {
+			continue
+		}
+
+		applier, ok := patchValue.Field(i).Interface().(patchApplier)
+		if !ok {
+			continue
+		}
+
+		state, value := applier.patchState()
+		if state == Absent {
+			continue
+		}
+
+		targetName := field.Name
+		if tag := field.Tag.Get("patch"); tag == "-" {
+			continue
+		} else if tag != "" {
+			targetName = tag
+		}
+
+		targetField := targetValue.FieldByName(targetName)
+		if !targetField.IsValid() || !targetField.CanSet() {
+			return fmt.Errorf("patch field %s has no settable field %s on %s", field.Name, targetName, targetValue.Type())
+		}
+
+		if err := applyPatchValue(targetField, state == Set, value); err != nil {
+			return fmt.Errorf("failed to apply patch field %s: %w", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func applyPatchValue(target reflect.Value, hasValue bool, value any) error {
+	if setter, ok := target.Addr().Interface().(valueSetter); ok {
+		if setter.setAny(value, hasValue) {
+			return nil
+		}
+
+		return fmt.Errorf("cannot assign %T to %s", value, target.Type())
+	}
+
+	if !hasValue {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+
+	switch {
+	case v.Type().AssignableTo(target.Type()):
+		target.Set(v)
+	case target.Kind() == reflect.Ptr && v.Type().AssignableTo(target.Type().Elem()):
+		ptr := reflect.New(target.Type().Elem())
+		ptr.Elem().Set(v)
+		target.Set(ptr)
+	default:
+		return fmt.Errorf("cannot assign %T to %s", value, target.Type())
+	}
+
+	return nil
+}
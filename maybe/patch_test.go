@@ -0,0 +1,137 @@
+package maybe
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type patchTestTarget struct {
+	Name     string
+	Nickname *string
+	Age      Maybe[int]
+	Email    string
+}
+
+type patchTestRequest struct {
+	Name     Patch[string] `json:"name"`
+	Nickname Patch[string] `json:"nickname"`
+	Age      Patch[int]    `json:"age"`
+	Contact  Patch[string] `json:"contact" patch:"Email"`
+}
+
+func TestPatch_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	var req patchTestRequest
+
+	err := json.Unmarshal([]byte(`{"name": "Jim", "nickname": null}`), &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, Set, req.Name.State())
+	assert.Equal(t, Null, req.Nickname.State())
+	assert.Equal(t, Absent, req.Age.State())
+
+	// Set and Null survive a round trip
+	req.Age = PatchWithValue(40)
+	req.Contact = PatchNull[string]()
+
+	out, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded patchTestRequest
+
+	err = json.Unmarshal(out, &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, req, decoded)
+}
+
+type patchTestEmbedded struct {
+	Team Patch[string] `json:"team"`
+}
+
+type patchTestUpdate struct {
+	patchTestEmbedded
+	Name     Patch[string] `json:"name"`
+	Nickname Patch[string] `json:"nickname"`
+	Age      Patch[int]
+	Hidden   Patch[string] `json:"-"`
+	Note     string        `json:"note,omitempty"`
+}
+
+func TestMarshalJSONOmitAbsent(t *testing.T) {
+	t.Parallel()
+
+	update := patchTestUpdate{
+		Name:     PatchWithValue("Jim"),
+		Nickname: PatchNull[string](),
+	}
+
+	out, err := MarshalJSONOmitAbsent(&update)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name": "Jim", "nickname": null}`, string(out))
+
+	var decoded patchTestUpdate
+
+	assert.NoError(t, json.Unmarshal(out, &decoded))
+	assert.Equal(t, update, decoded)
+	assert.True(t, decoded.Age.IsAbsent())
+	assert.True(t, decoded.Team.IsAbsent())
+
+	update.Team = PatchWithValue("core")
+	update.Age = PatchNull[int]()
+	update.Note = "hi"
+
+	out, err = MarshalJSONOmitAbsent(update)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"team": "core", "name": "Jim", "nickname": null, "Age": null, "note": "hi"}`, string(out))
+
+	// values that aren't structs are marshalled as usual
+	out, err = MarshalJSONOmitAbsent([]int{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "[1,2]", string(out))
+}
+
+func TestApplyPatch(t *testing.T) {
+	t.Parallel()
+
+	nickname := "jimbo"
+
+	target := patchTestTarget{
+		Name:     "James",
+		Nickname: &nickname,
+		Age:      WithValue(40),
+		Email:    "old@example.com",
+	}
+
+	err := ApplyPatch(&target, patchTestRequest{
+		Name:     PatchWithValue("Jim"),
+		Nickname: PatchNull[string](),
+		Contact:  PatchWithValue("new@example.com"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, "Jim", target.Name)
+	assert.Nil(t, target.Nickname)
+	assert.Equal(t, 40, target.Age.Or(0))
+	assert.Equal(t, "new@example.com", target.Email)
+
+	err = ApplyPatch(&target, patchTestRequest{Age: PatchNull[int]()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.False(t, target.Age.HasValue())
+
+	err = ApplyPatch(target, patchTestRequest{})
+	assert.Error(t, err)
+}
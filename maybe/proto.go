@@ -0,0 +1,104 @@
+package maybe
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// protoWrapper is satisfied by every protobuf well known wrapper type, such as *wrapperspb.StringValue
+type protoWrapper[T any] interface {
+	GetValue() T
+	ProtoReflect() protoreflect.Message
+}
+
+// FromProto creates a Maybe from a protobuf wrapper, a nil wrapper creates an empty Maybe
+//
+//	name := maybe.FromProto[string](req.Name)
+func FromProto[T any, W protoWrapper[T]](wrapper W) Maybe[T] {
+	if !wrapper.ProtoReflect().IsValid() {
+		return Empty[T]()
+	}
+
+	return WithValue(wrapper.GetValue())
+}
+
+func toProto[T any, W any](m Maybe[T], wrap func(T) W) W {
+	if !m.hasValue {
+		var empty W
+		return empty
+	}
+
+	return wrap(m.value)
+}
+
+func FromStringValue(w *wrapperspb.StringValue) Maybe[string] {
+	return FromProto[string](w)
+}
+
+func ToStringValue(m Maybe[string]) *wrapperspb.StringValue {
+	return toProto(m, wrapperspb.String)
+}
+
+func FromBoolValue(w *wrapperspb.BoolValue) Maybe[bool] {
+	return FromProto[bool](w)
+}
+
+func ToBoolValue(m Maybe[bool]) *wrapperspb.BoolValue {
+	return toProto(m, wrapperspb.Bool)
+}
+
+func FromInt32Value(w *wrapperspb.Int32Value) Maybe[int32] {
+	return FromProto[int32](w)
+}
+
+func ToInt32Value(m Maybe[int32]) *wrapperspb.Int32Value {
+	return toProto(m, wrapperspb.Int32)
+}
+
+func FromInt64Value(w *wrapperspb.Int64Value) Maybe[int64] {
+	return FromProto[int64](w)
+}
+
+func ToInt64Value(m Maybe[int64]) *wrapperspb.Int64Value {
+	return toProto(m, wrapperspb.Int64)
+}
+
+func FromUInt32Value(w *wrapperspb.UInt32Value) Maybe[uint32] {
+	return FromProto[uint32](w)
+}
+
+func ToUInt32Value(m Maybe[uint32]) *wrapperspb.UInt32Value {
+	return toProto(m, wrapperspb.UInt32)
+}
+
+func FromUInt64Value(w *wrapperspb.UInt64Value) Maybe[uint64] {
+	return FromProto[uint64](w)
+}
+
+func ToUInt64Value(m Maybe[uint64]) *wrapperspb.UInt64Value {
+	return toProto(m, wrapperspb.UInt64)
+}
+
+func FromFloatValue(w *wrapperspb.FloatValue) Maybe[float32] {
+	return FromProto[float32](w)
+}
+
+func ToFloatValue(m Maybe[float32]) *wrapperspb.FloatValue {
+	return toProto(m, wrapperspb.Float)
+}
+
+func FromDoubleValue(w *wrapperspb.DoubleValue) Maybe[float64] {
+	return FromProto[float64](w)
+}
+
+func ToDoubleValue(m Maybe[float64]) *wrapperspb.DoubleValue {
+	return toProto(m, wrapperspb.Double)
+}
+
+func FromBytesValue(w *wrapperspb.BytesValue) Maybe[[]byte] {
+	return FromProto[[]byte](w)
+}
+
+func ToBytesValue(m Maybe[[]byte]) *wrapperspb.BytesValue {
+	return toProto(m, wrapperspb.Bytes)
+}
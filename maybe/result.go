@@ -0,0 +1,107 @@
+package maybe
+
+import "errors"
+
+// ErrNoValue is the error of Results created from a nil error, which would otherwise report ok without a value
+var ErrNoValue = errors.New("result has no value")
+
+// Result holds either a value or an error
+// it is meant for service layers that want to pass around the outcome of an operation, such as the results of a batch
+type Result[T any] struct {
+	value T
+	err   error
+}
+
+func Ok[T any](val T) Result[T] {
+	return Result[T]{value: val}
+}
+
+// Err creates a failed Result, a nil err is replaced with ErrNoValue
+func Err[T any](err error) Result[T] {
+	if err == nil {
+		err = ErrNoValue
+	}
+
+	return Result[T]{err: err}
+}
+
+// Try creates a Result from a regular go (value, error) return
+func Try[T any](val T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+
+	return Ok(val)
+}
+
+// OkOr converts a Maybe into a Result, using err when the maybe is empty, or ErrNoValue if err is nil
+func OkOr[T any](m Maybe[T], err error) Result[T] {
+	if v, ok := m.Value(); ok {
+		return Ok(v)
+	}
+
+	return Err[T](err)
+}
+
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+func (r Result[T]) Err() error {
+	return r.err
+}
+
+// Get returns the result as a regular go (value, error) pair
+func (r Result[T]) Get() (T, error) {
+	return r.value, r.err
+}
+
+func (r Result[T]) Or(defaultValue T) T {
+	if r.err != nil {
+		return defaultValue
+	}
+
+	return r.value
+}
+
+// Maybe discards the error, returning an empty maybe if there was one
+func (r Result[T]) Maybe() Maybe[T] {
+	if r.err != nil {
+		return Empty[T]()
+	}
+
+	return WithValue(r.value)
+}
+
+// MapResult transforms the value of an ok Result, errors are passed through untouched
+func MapResult[St any, Mt any](from Result[St], mapFunc func(value St) Mt) Result[Mt] {
+	if from.err != nil {
+		return Err[Mt](from.err)
+	}
+
+	return Ok(mapFunc(from.value))
+}
+
+// AndThen runs a fallible operation on the value of an ok Result, errors are passed through untouched
+func AndThen[St any, Mt any](from Result[St], then func(value St) (Mt, error)) Result[Mt] {
+	if from.err != nil {
+		return Err[Mt](from.err)
+	}
+
+	return Try(then(from.value))
+}
+
+// CollectResults returns every value, or the first error encountered
+func CollectResults[T any](results []Result[T]) ([]T, error) {
+	values := make([]T, 0, len(results))
+
+	for _, r := range results {
+		if r.err != nil {
+			return nil, r.err
+		}
+
+		values = append(values, r.value)
+	}
+
+	return values, nil
+}
@@ -0,0 +1,191 @@
+package maybe
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Column is a Maybe that can be used directly as a database column, ie. db.Exec(query, maybe.AsColumn(name))
+// Maybe can't implement driver.Valuer itself because its Value method already returns (T, bool), and changing it
+// would break every existing caller. Passing a plain Maybe to Exec or QueryRow fails with an unsupported type error
+// Column shadows Value with the driver.Valuer version, everything else (including json) comes from the embedded Maybe
+type Column[T any] struct {
+	Maybe[T]
+}
+
+func AsColumn[T any](m Maybe[T]) Column[T] {
+	return Column[T]{Maybe: m}
+}
+
+// Value implements driver.Valuer
+func (c Column[T]) Value() (driver.Value, error) {
+	return c.Maybe.DriverValue()
+}
+
+// Scan implements sql.Scanner, a NULL column becomes an empty Maybe
+// if T implements sql.Scanner it is used to read the value, otherwise common driver types are converted into T
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m *Maybe[T]) Scan(src any) error {
+	if src == nil {
+		*m = Empty[T]()
+		return nil
+	}
+
+	var value T
+
+	if scanner, ok := any(&value).(sql.Scanner); ok {
+		if err := scanner.Scan(src); err != nil {
+			return err
+		}
+	} else if err := assignSQLValue(reflect.ValueOf(&value).Elem(), src); err != nil {
+		return err
+	}
+
+	*m = WithValue(value)
+
+	return nil
+}
+
+// DriverValue returns the value that should be written to the database, an empty Maybe is written as NULL
+// if T implements driver.Valuer it is used, otherwise the value is converted with driver.DefaultParameterConverter
+//
+//goland:noinspection GoMixedReceiverTypes
+func (m Maybe[T]) DriverValue() (driver.Value, error) {
+	if !m.hasValue {
+		return nil, nil
+	}
+
+	if valuer, ok := any(m.value).(driver.Valuer); ok {
+		return valuer.Value()
+	}
+
+	return driver.DefaultParameterConverter.ConvertValue(m.value)
+}
+
+// ConvertFrom creates a Maybe from any driver.Valuer, such as null.String or sql.NullInt64
+// invalid (NULL) values become an empty Maybe
+func ConvertFrom[T any](valuer driver.Valuer) (Maybe[T], error) {
+	var m Maybe[T]
+
+	if valuer == nil || reflect.ValueOf(valuer).Kind() == reflect.Ptr && reflect.ValueOf(valuer).IsNil() {
+		return m, nil
+	}
+
+	value, err := valuer.Value()
+	if err != nil {
+		return m, err
+	}
+
+	err = m.Scan(value)
+
+	return m, err
+}
+
+// ConvertTo creates a nullable type N, such as null.String or sql.NullInt64, from a Maybe
+// an empty Maybe becomes an invalid (NULL) N
+//
+//	nullName, err := maybe.ConvertTo[null.String](name)
+func ConvertTo[N any, PN interface {
+	*N
+	sql.Scanner
+}, T any](m Maybe[T]) (N, error) {
+	var n N
+
+	value, err := m.DriverValue()
+	if err != nil {
+		return n, err
+	}
+
+	err = PN(&n).Scan(value)
+
+	return n, err
+}
+
+//revive:disable:cyclomatic Every supported driver type needs its own branch
+func assignSQLValue(dest reflect.Value, src any) error {
+	srcValue := reflect.ValueOf(src)
+
+	if srcValue.Type().AssignableTo(dest.Type()) {
+		// copy byte slices, the driver may reuse the underlying buffer
+		if b, ok := src.([]byte); ok {
+			src = append([]byte(nil), b...)
+			srcValue = reflect.ValueOf(src)
+		}
+
+		dest.Set(srcValue)
+
+		return nil
+	}
+
+	var asString string
+
+	switch s := src.(type) {
+	case []byte:
+		asString = string(s)
+	case string:
+		asString = s
+	case time.Time:
+		asString = s.Format(time.RFC3339Nano)
+	default:
+		asString = fmt.Sprint(src)
+	}
+
+	switch dest.Kind() {
+	case reflect.String:
+		dest.SetString(asString)
+		return nil
+	case reflect.Slice:
+		if dest.Type().Elem().Kind() == reflect.Uint8 {
+			dest.SetBytes([]byte(asString))
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(asString, 10, dest.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("maybe: cannot scan %T into %s: %w", src, dest.Type(), err)
+		}
+
+		dest.SetInt(n)
+
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(asString, 10, dest.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("maybe: cannot scan %T into %s: %w", src, dest.Type(), err)
+		}
+
+		dest.SetUint(n)
+
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(asString, dest.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("maybe: cannot scan %T into %s: %w", src, dest.Type(), err)
+		}
+
+		dest.SetFloat(n)
+
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(asString)
+		if err != nil {
+			return fmt.Errorf("maybe: cannot scan %T into %s: %w", src, dest.Type(), err)
+		}
+
+		dest.SetBool(b)
+
+		return nil
+	}
+
+	if srcValue.Type().ConvertibleTo(dest.Type()) {
+		dest.Set(srcValue.Convert(dest.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("maybe: cannot scan %T into %s", src, dest.Type())
+}
@@ -0,0 +1,189 @@
+package maybe
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/volatiletech/null/v8"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upperString is stored upper cased, to check that Scanner and Valuer implementations on T are used
+type upperString string
+
+func (u *upperString) Scan(src any) error {
+	*u = upperString(strings.ToLower(src.(string)))
+	return nil
+}
+
+func (u upperString) Value() (driver.Value, error) {
+	return strings.ToUpper(string(u)), nil
+}
+
+func TestMaybe_Scan(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		scan    func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{"null", func() (any, error) { return scanInto[string](nil) }, Empty[string](), false},
+		{"string", func() (any, error) { return scanInto[string]("hi") }, WithValue("hi"), false},
+		{"bytes into string", func() (any, error) { return scanInto[string]([]byte("hi")) }, WithValue("hi"), false},
+		{"bytes", func() (any, error) { return scanInto[[]byte]([]byte("hi")) }, WithValue([]byte("hi")), false},
+		{"int64 into int", func() (any, error) { return scanInto[int](int64(5)) }, WithValue(5), false},
+		{"string into int", func() (any, error) { return scanInto[int]("12") }, WithValue(12), false},
+		{"int64 into uint8", func() (any, error) { return scanInto[uint8](int64(7)) }, WithValue(uint8(7)), false},
+		{"float", func() (any, error) { return scanInto[float64](1.5) }, WithValue(1.5), false},
+		{"bool", func() (any, error) { return scanInto[bool](true) }, WithValue(true), false},
+		{"bytes into bool", func() (any, error) { return scanInto[bool]([]byte("1")) }, WithValue(true), false},
+		{"time", func() (any, error) { return scanInto[time.Time](now) }, WithValue(now), false},
+		{"null.String", func() (any, error) { return scanInto[null.String]("x") }, WithValue(null.StringFrom("x")), false},
+		{"sql.NullInt64", func() (any, error) { return scanInto[sql.NullInt64](int64(3)) }, WithValue(sql.NullInt64{Int64: 3, Valid: true}), false},
+		{"scanner", func() (any, error) { return scanInto[upperString]("ABC") }, WithValue(upperString("abc")), false},
+		{"string into int mismatch", func() (any, error) { return scanInto[int]("abc") }, nil, true},
+		{"int into bool mismatch", func() (any, error) { return scanInto[bool](int64(5)) }, nil, true},
+		{"time into int mismatch", func() (any, error) { return scanInto[int](now) }, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.scan()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("bytes are copied", func(t *testing.T) {
+		src := []byte("hi")
+
+		m, err := scanInto[[]byte](src)
+		assert.NoError(t, err)
+
+		src[0] = 'x'
+		assert.Equal(t, []byte("hi"), m.Or(nil))
+	})
+}
+
+func scanInto[T any](src any) (Maybe[T], error) {
+	var m Maybe[T]
+	err := m.Scan(src)
+
+	return m, err
+}
+
+func TestMaybe_DriverValue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value interface{ DriverValue() (driver.Value, error) }
+		want  driver.Value
+	}{
+		{"empty", Empty[string](), nil},
+		{"string", WithValue("hi"), "hi"},
+		{"int", WithValue(5), int64(5)},
+		{"bool", WithValue(true), true},
+		{"null.String", WithValue(null.StringFrom("x")), "x"},
+		{"invalid null.String", WithValue(null.String{}), nil},
+		{"sql.NullInt64", WithValue(sql.NullInt64{Int64: 3, Valid: true}), int64(3)},
+		{"valuer", WithValue(upperString("abc")), "ABC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.value.DriverValue()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := WithValue(struct{}{}).DriverValue()
+	assert.Error(t, err)
+
+	var valuer driver.Valuer = AsColumn(WithValue("hi"))
+
+	value, err := valuer.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, "hi", value)
+}
+
+func TestConvertFrom(t *testing.T) {
+	t.Parallel()
+
+	var nilString *sql.NullString
+
+	tests := []struct {
+		name    string
+		convert func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{"null.String", func() (any, error) { return ConvertFrom[string](null.StringFrom("a")) }, WithValue("a"), false},
+		{"invalid null.String", func() (any, error) { return ConvertFrom[string](null.String{}) }, Empty[string](), false},
+		{"null.Int", func() (any, error) { return ConvertFrom[int](null.IntFrom(4)) }, WithValue(4), false},
+		{"sql.NullInt64", func() (any, error) { return ConvertFrom[int64](sql.NullInt64{Int64: 3, Valid: true}) }, WithValue(int64(3)), false},
+		{"sql.NullBool", func() (any, error) { return ConvertFrom[bool](sql.NullBool{}) }, Empty[bool](), false},
+		{"nil pointer", func() (any, error) { return ConvertFrom[string](nilString) }, Empty[string](), false},
+		{"nil", func() (any, error) { return ConvertFrom[string](nil) }, Empty[string](), false},
+		{"mismatch", func() (any, error) { return ConvertFrom[int](null.StringFrom("abc")) }, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.convert()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConvertTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		convert func() (any, error)
+		want    any
+		wantErr bool
+	}{
+		{"null.String", func() (any, error) { return ConvertTo[null.String](WithValue("a")) }, null.StringFrom("a"), false},
+		{"empty null.String", func() (any, error) { return ConvertTo[null.String](Empty[string]()) }, null.String{}, false},
+		{"sql.NullInt64", func() (any, error) { return ConvertTo[sql.NullInt64](WithValue(3)) }, sql.NullInt64{Int64: 3, Valid: true}, false},
+		{"empty sql.NullInt64", func() (any, error) { return ConvertTo[sql.NullInt64](Empty[int]()) }, sql.NullInt64{}, false},
+		{"null.Bool", func() (any, error) { return ConvertTo[null.Bool](WithValue(true)) }, null.BoolFrom(true), false},
+		{"mismatch", func() (any, error) { return ConvertTo[sql.NullInt64](WithValue("abc")) }, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.convert()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
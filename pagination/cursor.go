@@ -0,0 +1,86 @@
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"strings"
+)
+
+// minCursorKeyLength is the shortest key accepted for signing cursors
+const minCursorKeyLength = 16
+
+// CursorCodec encodes cursor values into opaque strings, signed so that clients can't modify them
+// cursors are not encrypted, clients can decode them, so don't put anything secret in a cursor
+type CursorCodec struct {
+	key []byte
+}
+
+func NewCursorCodec(key []byte) (*CursorCodec, error) {
+	if len(key) < minCursorKeyLength {
+		return nil, errors.New("cursor key must be at least 16 bytes")
+	}
+
+	return &CursorCodec{key: key}, nil
+}
+
+// Encode serializes v as json and signs it, the result is url safe
+func (c *CursorCodec) Encode(v any) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", ferr.Wrap(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode verifies a cursor created by Encode and reads it into v
+// invalid or tampered cursors return a validation error for the cursor field
+func (c *CursorCodec) Decode(cursor string, v any) *ferr.Error {
+	encodedPayload, encodedSig, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return invalidCursor()
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return invalidCursor()
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, c.sign(payload)) {
+		return invalidCursor()
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return invalidCursor()
+	}
+
+	return nil
+}
+
+// NextCursor encodes v as the cursor for the next page if there is one, otherwise it returns an empty cursor
+func (c *CursorCodec) NextCursor(hasMore bool, v any) (string, error) {
+	if !hasMore {
+		return "", nil
+	}
+
+	return c.Encode(v)
+}
+
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+func invalidCursor() *ferr.Error {
+	return ferr.InvalidFields(&ferr.FieldError{
+		Field:   "cursor",
+		Message: "cursor is invalid",
+	})
+}
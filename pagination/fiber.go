@@ -0,0 +1,58 @@
+package pagination
+
+import (
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// ParseQuery reads the limit, offset and cursor query parameters, and clamps the limit
+// malformed values return a validation error with a field error for each bad parameter
+func ParseQuery(c *fiber.Ctx, limits ...Limits) (*PageRequest, *ferr.Error) {
+	var (
+		req         PageRequest
+		fieldErrors []*ferr.FieldError
+	)
+
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			fieldErrors = append(fieldErrors, &ferr.FieldError{
+				Field:   "limit",
+				Message: fmt.Sprintf("limit must be a whole number greater than 0, got %q", raw),
+			})
+		}
+
+		req.Limit = limit
+	}
+
+	if raw := c.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			fieldErrors = append(fieldErrors, &ferr.FieldError{
+				Field:   "offset",
+				Message: fmt.Sprintf("offset must be a whole number of at least 0, got %q", raw),
+			})
+		}
+
+		req.Offset = offset
+	}
+
+	req.Cursor = c.Query("cursor")
+
+	if req.Cursor != "" && req.Offset != 0 {
+		fieldErrors = append(fieldErrors, &ferr.FieldError{
+			Field:   "offset",
+			Message: "offset can't be used together with cursor",
+		})
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, ferr.InvalidFields(fieldErrors...)
+	}
+
+	req.Clamp(limits...)
+
+	return &req, nil
+}
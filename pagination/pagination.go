@@ -0,0 +1,207 @@
+package pagination
+
+import (
+	"context"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+)
+
+const (
+	DefaultLimit    = 25
+	DefaultMaxLimit = 100
+)
+
+// Limits controls how PageRequest.Clamp adjusts the requested limit
+type Limits struct {
+	// Default is used when no limit was requested, defaults to DefaultLimit
+	Default int
+
+	// Max is the largest limit allowed, larger limits are lowered to it, defaults to DefaultMaxLimit
+	Max int
+}
+
+// PageRequest describes which page of a list to load
+// offset based lists use Offset, cursor based lists use Cursor, which is opaque to clients
+type PageRequest struct {
+	Limit  int    `json:"limit" query:"limit"`
+	Offset int    `json:"offset,omitempty" query:"offset"`
+	Cursor string `json:"cursor,omitempty" query:"cursor"`
+}
+
+// Page is a single page of a list
+type Page[T any] struct {
+	Items []T `json:"items"`
+
+	// HasMore is true when there are more items after this page
+	HasMore bool `json:"has_more"`
+
+	// NextCursor is the cursor for the next page of a cursor based list, it is empty on the last page
+	NextCursor string `json:"next_cursor,omitempty"`
+
+	// Offset and Limit echo the request for offset based lists
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+
+	// TotalCount is the number of items in the whole list, if the loader knows it
+	TotalCount *int `json:"total_count,omitempty"`
+}
+
+// Loader loads a single page of a list
+type Loader[T any] func(ctx context.Context, req PageRequest) (*Page[T], error)
+
+// Clamp replaces a missing limit with the default, lowers the limit to the max, and raises negative offsets to 0
+func (r *PageRequest) Clamp(limits ...Limits) {
+	l := resolveLimits(limits)
+
+	if r.Limit <= 0 {
+		r.Limit = l.Default
+	}
+
+	if r.Limit > l.Max {
+		r.Limit = l.Max
+	}
+
+	if r.Offset < 0 {
+		r.Offset = 0
+	}
+}
+
+// NewOffsetPage builds a page of an offset based list with a known total count
+func NewOffsetPage[T any](items []T, req PageRequest, totalCount int) *Page[T] {
+	return &Page[T]{
+		Items:      nonNil(items),
+		HasMore:    req.Offset+len(items) < totalCount,
+		Offset:     req.Offset,
+		Limit:      req.Limit,
+		TotalCount: &totalCount,
+	}
+}
+
+// NewCursorPage builds a page of a cursor based list, nextCursor should be empty on the last page
+func NewCursorPage[T any](items []T, req PageRequest, nextCursor string) *Page[T] {
+	return &Page[T]{
+		Items:      nonNil(items),
+		HasMore:    nextCursor != "",
+		NextCursor: nextCursor,
+		Limit:      req.Limit,
+	}
+}
+
+// TrimExtra supports the common pattern of loading limit+1 items to find out if there is another page
+// it returns at most limit items, and whether there were more
+func TrimExtra[T any](items []T, limit int) ([]T, bool) {
+	if len(items) > limit {
+		return items[:limit], true
+	}
+
+	return items, false
+}
+
+// MapPage converts the items of a page, keeping the paging information
+func MapPage[I any, O any](page *Page[I], transform func(item I) (O, error)) (*Page[O], error) {
+	items := make([]O, len(page.Items))
+
+	for i, item := range page.Items {
+		transformed, err := transform(item)
+		if err != nil {
+			return nil, ferr.Wrap(err)
+		}
+
+		items[i] = transformed
+	}
+
+	return &Page[O]{
+		Items:      items,
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
+		Offset:     page.Offset,
+		Limit:      page.Limit,
+		TotalCount: page.TotalCount,
+	}, nil
+}
+
+// EachPage calls load for every page of a list starting at req, and passes each page to fn
+// cursor based pages are followed with NextCursor, otherwise the offset is advanced by the number of items loaded
+// a page of a cursor request that has more items but no NextCursor is an error
+func EachPage[T any](ctx context.Context, req PageRequest, load Loader[T], fn func(page *Page[T]) error) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return ferr.Wrap(err)
+		}
+
+		page, err := load(ctx, req)
+		if err != nil {
+			return ferr.Wrap(err)
+		}
+
+		if err := fn(page); err != nil {
+			return ferr.Wrap(err)
+		}
+
+		if !page.HasMore {
+			return nil
+		}
+
+		if page.NextCursor != "" {
+			req.Cursor = page.NextCursor
+			continue
+		}
+
+		// advancing the offset of a cursor request would mix the two modes, the loader has to return a next cursor
+		if req.Cursor != "" {
+			return ferr.Wrap(fmt.Errorf("page of a cursor request has more items, but no next cursor"))
+		}
+
+		// an empty page can't advance the offset, so it is always treated as the last one
+		if len(page.Items) == 0 {
+			return nil
+		}
+
+		req.Offset += len(page.Items)
+	}
+}
+
+// All loads every page of a list starting at req, and returns all of their items
+func All[T any](ctx context.Context, req PageRequest, load Loader[T]) ([]T, error) {
+	var items []T
+
+	err := EachPage(ctx, req, load, func(page *Page[T]) error {
+		items = append(items, page.Items...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+func resolveLimits(limits []Limits) Limits {
+	l := Limits{}
+	if len(limits) > 0 {
+		l = limits[0]
+	}
+
+	if l.Default <= 0 {
+		l.Default = DefaultLimit
+	}
+
+	if l.Max <= 0 {
+		l.Max = DefaultMaxLimit
+	}
+
+	if l.Default > l.Max {
+		l.Default = l.Max
+	}
+
+	return l
+}
+
+// nonNil makes sure pages serialize their items as [] instead of null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+
+	return items
+}
@@ -0,0 +1,154 @@
+package pagination
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClamp(t *testing.T) {
+	t.Parallel()
+
+	req := PageRequest{Offset: -5}
+	req.Clamp()
+	assert.Equal(t, PageRequest{Limit: DefaultLimit}, req)
+
+	req = PageRequest{Limit: 500}
+	req.Clamp(Limits{Default: 10, Max: 50})
+	assert.Equal(t, 50, req.Limit)
+}
+
+func TestCursorCodec(t *testing.T) {
+	t.Parallel()
+
+	type cursor struct {
+		AfterID string `json:"after_id"`
+	}
+
+	codec, err := NewCursorCodec([]byte("0123456789abcdef"))
+	assert.NoError(t, err)
+
+	encoded, err := codec.Encode(cursor{AfterID: "usr_1"})
+	assert.NoError(t, err)
+
+	var decoded cursor
+	assert.Nil(t, codec.Decode(encoded, &decoded))
+	assert.Equal(t, "usr_1", decoded.AfterID)
+
+	// a cursor signed with another key is rejected
+	other, _ := NewCursorCodec([]byte("fedcba9876543210"))
+	forged, _ := other.Encode(cursor{AfterID: "usr_2"})
+
+	fe := codec.Decode(forged, &decoded)
+	if assert.NotNil(t, fe) {
+		assert.Equal(t, http.StatusBadRequest, *fe.HTTPCode)
+		assert.Equal(t, "cursor", fe.Fields[0].Field)
+	}
+
+	assert.NotNil(t, codec.Decode("not-a-cursor", &decoded))
+
+	_, err = NewCursorCodec([]byte("short"))
+	assert.Error(t, err)
+}
+
+func TestParseQuery(t *testing.T) {
+	t.Parallel()
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		req, fe := ParseQuery(c, Limits{Max: 50})
+		if fe != nil {
+			return c.Status(*fe.HTTPCode).JSON(fe.Fields)
+		}
+
+		return c.JSON(req)
+	})
+
+	do := func(target string, out any) int {
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(out))
+
+		return res.StatusCode
+	}
+
+	var req PageRequest
+	assert.Equal(t, http.StatusOK, do("/?limit=1000&offset=20", &req))
+	assert.Equal(t, PageRequest{Limit: 50, Offset: 20}, req)
+
+	var fields []map[string]any
+	assert.Equal(t, http.StatusBadRequest, do("/?limit=abc&offset=-1", &fields))
+	assert.Len(t, fields, 2)
+	assert.Equal(t, "limit", fields[0]["field"])
+	assert.Equal(t, "offset", fields[1]["field"])
+}
+
+func TestAll(t *testing.T) {
+	t.Parallel()
+
+	data := []int{1, 2, 3, 4, 5, 6, 7}
+
+	offsetLoader := func(ctx context.Context, req PageRequest) (*Page[int], error) {
+		end := req.Offset + req.Limit
+		if end > len(data) {
+			end = len(data)
+		}
+
+		return NewOffsetPage(data[req.Offset:end], req, len(data)), nil
+	}
+
+	items, err := All(context.Background(), PageRequest{Limit: 3}, offsetLoader)
+	assert.NoError(t, err)
+	assert.Equal(t, data, items)
+
+	codec, _ := NewCursorCodec([]byte("0123456789abcdef"))
+
+	cursorLoader := func(ctx context.Context, req PageRequest) (*Page[int], error) {
+		start := 0
+		if req.Cursor != "" {
+			if fe := codec.Decode(req.Cursor, &start); fe != nil {
+				return nil, fe
+			}
+		}
+
+		items, hasMore := TrimExtra(data[start:], req.Limit)
+
+		next, err := codec.NextCursor(hasMore, start+len(items))
+		if err != nil {
+			return nil, err
+		}
+
+		return NewCursorPage(items, req, next), nil
+	}
+
+	items, err = All(context.Background(), PageRequest{Limit: 2}, cursorLoader)
+	assert.NoError(t, err)
+	assert.Equal(t, data, items)
+
+	// a cursor page that has more items but no next cursor can't fall back to offsets
+	calls := 0
+
+	missingCursorLoader := func(ctx context.Context, req PageRequest) (*Page[int], error) {
+		calls++
+
+		page, err := cursorLoader(ctx, req)
+		if err != nil || calls < 2 {
+			return page, err
+		}
+
+		page.NextCursor = ""
+
+		return page, nil
+	}
+
+	_, err = All(context.Background(), PageRequest{Limit: 2}, missingCursorLoader)
+	assert.Error(t, err)
+	assert.Equal(t, 2, calls)
+}
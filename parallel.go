@@ -0,0 +1,177 @@
+package fcthelp
+
+import (
+	"context"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultParallelConcurrency is the concurrency used when ParallelOpts.Concurrency is not set
+const DefaultParallelConcurrency = 10
+
+type ParallelOpts struct {
+	// Concurrency is the maximum number of items processed at once, defaults to DefaultParallelConcurrency
+	Concurrency int
+
+	// CollectAllErrors keeps processing every item after an error, and returns all errors as a *MultiError
+	// by default the first error cancels the context passed to the remaining items, errgroup style
+	CollectAllErrors bool
+}
+
+// IndexedError is an error that occurred while processing the item at Index
+type IndexedError struct {
+	Index int
+	Err   error
+}
+
+func (e *IndexedError) Error() string {
+	return fmt.Sprintf("index %d: %v", e.Index, e.Err)
+}
+
+func (e *IndexedError) Unwrap() error {
+	return e.Err
+}
+
+// MultiError is returned by parallel helpers in CollectAllErrors mode, errors are sorted by index
+type MultiError struct {
+	Errors []*IndexedError
+}
+
+func (m *MultiError) Error() string {
+	messages := make([]string, len(m.Errors))
+	for i, err := range m.Errors {
+		messages[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d errors occurred: %s", len(m.Errors), strings.Join(messages, "; "))
+}
+
+func (m *MultiError) Unwrap() []error {
+	errs := make([]error, len(m.Errors))
+	for i, err := range m.Errors {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// ParallelMapSlice is MapSlice with a bounded number of transforms running concurrently
+// the output is in the same order as the input
+func ParallelMapSlice[I any, T any](ctx context.Context, input []I, opts *ParallelOpts, transform func(ctx context.Context, item I, index int) (T, error)) ([]T, error) {
+	result := make([]T, len(input))
+
+	err := parallelEach(ctx, input, opts, func(ctx context.Context, item I, index int) error {
+		transformed, err := transform(ctx, item, index)
+		if err != nil {
+			return err
+		}
+
+		result[index] = transformed
+
+		return nil
+	})
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return result, nil
+}
+
+// ParallelFilterMap is FilterMap with a bounded number of calls to fm running concurrently
+// the output is in the same order as the input
+func ParallelFilterMap[I any, O any](ctx context.Context, input []I, opts *ParallelOpts, fm func(ctx context.Context, item I, idx int) (*O, error)) ([]*O, error) {
+	// ParallelMapSlice has already wrapped the error
+	mapped, err := ParallelMapSlice(ctx, input, opts, fm)
+	if err != nil {
+		return nil, err
+	}
+
+	var outSlice []*O
+
+	for _, output := range mapped {
+		if !IsNil(output) {
+			outSlice = append(outSlice, output)
+		}
+	}
+
+	return outSlice, nil
+}
+
+// parallelEach runs fn for every item, with at most opts.Concurrency running at once
+//
+//revive:disable:cyclomatic Coordinating the goroutines is easier to follow in one place
+func parallelEach[I any](parentCtx context.Context, input []I, opts *ParallelOpts, fn func(ctx context.Context, item I, index int) error) error {
+	if opts == nil {
+		opts = &ParallelOpts{}
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultParallelConcurrency
+	}
+
+	ctx, cancel := context.WithCancel(parentCtx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		allErrs  []*IndexedError
+	)
+
+	semaphore := make(chan struct{}, concurrency)
+
+	for i, item := range input {
+		select {
+		case semaphore <- struct{}{}:
+		case <-ctx.Done():
+		}
+
+		// stop scheduling new items once the first error has occurred, or the parent context is done
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+
+		go func(index int, item I) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			err := fn(ctx, item, index)
+			if err == nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if opts.CollectAllErrors {
+				allErrs = append(allErrs, &IndexedError{Index: index, Err: err})
+			} else if firstErr == nil {
+				firstErr = &IndexedError{Index: index, Err: err}
+				cancel()
+			}
+		}(i, item)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+
+	if len(allErrs) > 0 {
+		sort.Slice(allErrs, func(i, j int) bool {
+			return allErrs[i].Index < allErrs[j].Index
+		})
+
+		return &MultiError{Errors: allErrs}
+	}
+
+	return parentCtx.Err()
+}
@@ -0,0 +1,158 @@
+package fcthelp
+
+import (
+	"context"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParallelMapSlice(t *testing.T) {
+	t.Parallel()
+
+	input := make([]int, 50)
+	for i := range input {
+		input[i] = i
+	}
+
+	var active, maxActive int32
+
+	result, err := ParallelMapSlice(context.Background(), input, &ParallelOpts{Concurrency: 3}, func(ctx context.Context, item int, index int) (int, error) {
+		current := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+
+		for {
+			seen := atomic.LoadInt32(&maxActive)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxActive, seen, current) {
+				break
+			}
+		}
+
+		// later items finish first, the output must still be in input order
+		time.Sleep(time.Duration(len(input)-item) * 50 * time.Microsecond)
+
+		return item * 2, nil
+	})
+
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxActive), int32(3))
+
+	for i, v := range result {
+		assert.Equal(t, i*2, v)
+	}
+
+	empty, err := ParallelMapSlice(context.Background(), []int(nil), nil, func(ctx context.Context, item int, index int) (int, error) {
+		return item, nil
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestParallelMapSliceErrors(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	t.Run("first error cancels the rest", func(t *testing.T) {
+		var started, cancelled int32
+
+		_, err := ParallelMapSlice(context.Background(), make([]int, 100), &ParallelOpts{Concurrency: 2}, func(ctx context.Context, _ int, index int) (int, error) {
+			atomic.AddInt32(&started, 1)
+
+			if index == 1 {
+				return 0, errBoom
+			}
+
+			select {
+			case <-ctx.Done():
+				atomic.AddInt32(&cancelled, 1)
+			case <-time.After(time.Second):
+			}
+
+			return 0, nil
+		})
+
+		assert.ErrorIs(t, err, errBoom)
+
+		var indexed *IndexedError
+		if assert.ErrorAs(t, err, &indexed) {
+			assert.Equal(t, 1, indexed.Index)
+		}
+
+		assert.Less(t, atomic.LoadInt32(&started), int32(100))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&cancelled))
+	})
+
+	t.Run("collect all errors", func(t *testing.T) {
+		_, err := ParallelMapSlice(context.Background(), []int{0, 1, 2, 3, 4}, &ParallelOpts{CollectAllErrors: true}, func(ctx context.Context, item int, index int) (int, error) {
+			if item%2 == 1 {
+				return 0, errBoom
+			}
+
+			return item, nil
+		})
+
+		var multi *MultiError
+		if assert.ErrorAs(t, err, &multi) && assert.Len(t, multi.Errors, 2) {
+			assert.Equal(t, 1, multi.Errors[0].Index)
+			assert.Equal(t, 3, multi.Errors[1].Index)
+		}
+
+		assert.ErrorIs(t, err, errBoom)
+	})
+
+	t.Run("parent context cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		var calls int32
+
+		_, err := ParallelMapSlice(ctx, make([]int, 20), &ParallelOpts{Concurrency: 1}, func(ctx context.Context, _ int, index int) (int, error) {
+			if atomic.AddInt32(&calls, 1) == 3 {
+				cancel()
+			}
+
+			return 0, nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, atomic.LoadInt32(&calls), int32(20))
+	})
+}
+
+func TestParallelFilterMap(t *testing.T) {
+	t.Parallel()
+
+	result, err := ParallelFilterMap(context.Background(), []int{1, 2, 3, 4, 5, 6}, &ParallelOpts{Concurrency: 2}, func(ctx context.Context, item int, idx int) (*int, error) {
+		if item%2 == 1 {
+			return nil, nil
+		}
+
+		doubled := item * 2
+
+		return &doubled, nil
+	})
+
+	assert.NoError(t, err)
+
+	if assert.Len(t, result, 3) {
+		assert.Equal(t, 4, *result[0])
+		assert.Equal(t, 8, *result[1])
+		assert.Equal(t, 12, *result[2])
+	}
+
+	errBoom := errors.New("boom")
+
+	_, err = ParallelFilterMap(context.Background(), []int{1}, nil, func(ctx context.Context, item int, idx int) (*int, error) {
+		return nil, errBoom
+	})
+
+	assert.ErrorIs(t, err, errBoom)
+
+	// the error is wrapped once by ParallelMapSlice, and not again by ParallelFilterMap
+	if wrapper, ok := err.(*ferr.Wrapper); assert.True(t, ok) {
+		assert.IsType(t, &IndexedError{}, wrapper.Unwrap())
+	}
+}
@@ -0,0 +1,239 @@
+package fcthelp
+
+import (
+	"context"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"math/rand"
+	"time"
+)
+
+const (
+	DefaultPollInterval       = 250 * time.Millisecond
+	DefaultRetryInterval      = 100 * time.Millisecond
+	DefaultRetryMaxAttempts   = 3
+	defaultPollResourceName   = "poll"
+	defaultBackoffMultiplier  = 2
+	defaultRetryMaxIntervalMS = 10_000
+)
+
+type PollOpts struct {
+	// Interval is the delay between checks, defaults to DefaultPollInterval
+	Interval time.Duration
+
+	// Multiplier grows the interval after every check, values <= 1 keep the interval fixed
+	Multiplier float64
+
+	// MaxInterval caps the interval when Multiplier is used, 0 means no cap
+	MaxInterval time.Duration
+
+	// Jitter randomizes every delay by up to this fraction of it, between 0 and 1
+	Jitter float64
+
+	// MaxAttempts stops polling after this many checks, 0 means no limit
+	MaxAttempts int
+
+	// Timeout stops polling after this long, 0 means polling only stops when ctx is done
+	Timeout time.Duration
+
+	// Resource is the name of the thing being waited on, it is used in timeout errors
+	Resource string
+}
+
+type RetryPolicy struct {
+	// MaxAttempts is the total number of times fn is called, defaults to DefaultRetryMaxAttempts
+	MaxAttempts int
+
+	// Interval is the delay before the first retry, defaults to DefaultRetryInterval
+	Interval time.Duration
+
+	// Multiplier grows the interval after every retry, defaults to 2, values < 1 are treated as 1
+	Multiplier float64
+
+	// MaxInterval caps the delay between retries, defaults to 10 seconds
+	MaxInterval time.Duration
+
+	// Jitter randomizes every delay by up to this fraction of it, between 0 and 1
+	Jitter float64
+
+	// ShouldRetry decides if an error can be retried
+	// by default every error is retried, unless it is a ferr.Error with retry info that says otherwise
+	ShouldRetry func(err error) bool
+}
+
+// Poll calls checker until it returns true, an error, or polling runs out of time or attempts
+// running out of attempts, opts.Timeout or the ctx deadline returns a ferr.ResourceTimedOut error
+// a cancelled ctx returns the ctx error
+func Poll(ctx context.Context, opts *PollOpts, checker func(ctx context.Context) (bool, error)) error {
+	if opts == nil {
+		opts = &PollOpts{}
+	}
+
+	resource := opts.Resource
+	if resource == "" {
+		resource = defaultPollResourceName
+	}
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+
+		defer cancel()
+	}
+
+	b := &backoff{
+		interval:    opts.Interval,
+		multiplier:  opts.Multiplier,
+		maxInterval: opts.MaxInterval,
+		jitter:      opts.Jitter,
+	}
+
+	if b.interval <= 0 {
+		b.interval = DefaultPollInterval
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return pollContextError(err, resource)
+		}
+
+		done, err := checker(ctx)
+		if err != nil {
+			return ferr.Wrap(err)
+		}
+
+		if done {
+			return nil
+		}
+
+		if opts.MaxAttempts > 0 && attempt >= opts.MaxAttempts {
+			return ferr.ResourceTimedOut(resource)
+		}
+
+		if err := sleepCtx(ctx, b.next()); err != nil {
+			return pollContextError(err, resource)
+		}
+	}
+}
+
+// Retry calls fn until it succeeds, it returns an error that can't be retried, or it runs out of attempts
+// if an error is a ferr.Error with retry info, its WaitTimeMS is used as the delay before the next attempt
+func Retry(ctx context.Context, policy *RetryPolicy, fn func(ctx context.Context) error) error {
+	_, err := RetryValue(ctx, policy, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+
+	return err
+}
+
+// RetryValue is Retry for functions that return a value
+//
+//revive:disable:cyclomatic Each exit condition of the retry loop is handled explicitly
+func RetryValue[T any](ctx context.Context, policy *RetryPolicy, fn func(ctx context.Context) (T, error)) (T, error) {
+	if policy == nil {
+		policy = &RetryPolicy{}
+	}
+
+	maxAttempts := Tern(policy.MaxAttempts > 0, policy.MaxAttempts, DefaultRetryMaxAttempts)
+
+	b := &backoff{
+		interval:    Tern(policy.Interval > 0, policy.Interval, DefaultRetryInterval),
+		multiplier:  Tern(policy.Multiplier > 0, policy.Multiplier, defaultBackoffMultiplier),
+		maxInterval: Tern(policy.MaxInterval > 0, policy.MaxInterval, defaultRetryMaxIntervalMS*time.Millisecond),
+		jitter:      policy.Jitter,
+	}
+
+	shouldRetry := policy.ShouldRetry
+	if shouldRetry == nil {
+		shouldRetry = defaultShouldRetry
+	}
+
+	var zero T
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return zero, ferr.Wrap(err)
+		}
+
+		value, err := fn(ctx)
+		if err == nil {
+			return value, nil
+		}
+
+		if !shouldRetry(err) {
+			return zero, ferr.Wrap(err)
+		}
+
+		if attempt >= maxAttempts {
+			return zero, ferr.Wrapf(err, "gave up after %d attempts", attempt)
+		}
+
+		delay := b.next()
+
+		var fctErr *ferr.Error
+		if errors.As(err, &fctErr) && fctErr.Retry != nil && fctErr.Retry.WaitTimeMS > 0 {
+			delay = time.Duration(fctErr.Retry.WaitTimeMS) * time.Millisecond
+		}
+
+		if err := sleepCtx(ctx, delay); err != nil {
+			return zero, ferr.Wrap(err)
+		}
+	}
+}
+
+func defaultShouldRetry(err error) bool {
+	var fctErr *ferr.Error
+	if errors.As(err, &fctErr) && fctErr.Retry != nil {
+		return fctErr.Retry.ShouldRetry
+	}
+
+	return true
+}
+
+func pollContextError(err error, resource string) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ferr.ResourceTimedOut(resource).WithUnderlying(err)
+	}
+
+	return ferr.Wrap(err)
+}
+
+// sleepCtx waits for d, returning early with the ctx error if ctx is done first
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff produces a sequence of delays, growing exponentially with optional jitter
+type backoff struct {
+	interval    time.Duration
+	multiplier  float64
+	maxInterval time.Duration
+	jitter      float64
+}
+
+func (b *backoff) next() time.Duration {
+	delay := b.interval
+
+	if b.jitter > 0 {
+		// rand's top level functions are safe for concurrent use
+		delay += time.Duration((rand.Float64()*2 - 1) * b.jitter * float64(delay))
+	}
+
+	if b.multiplier > 1 {
+		b.interval = time.Duration(float64(b.interval) * b.multiplier)
+
+		if b.maxInterval > 0 && b.interval > b.maxInterval {
+			b.interval = b.maxInterval
+		}
+	}
+
+	return delay
+}
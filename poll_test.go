@@ -0,0 +1,181 @@
+package fcthelp
+
+import (
+	"context"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestPoll(t *testing.T) {
+	t.Parallel()
+
+	t.Run("until done", func(t *testing.T) {
+		calls := 0
+
+		err := Poll(context.Background(), &PollOpts{Interval: time.Millisecond, Multiplier: 2, MaxInterval: 4 * time.Millisecond}, func(ctx context.Context) (bool, error) {
+			calls++
+			return calls == 5, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 5, calls)
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		calls := 0
+
+		err := Poll(context.Background(), &PollOpts{Interval: time.Millisecond, MaxAttempts: 3, Resource: "job"}, func(ctx context.Context) (bool, error) {
+			calls++
+			return false, nil
+		})
+
+		assert.Equal(t, 3, calls)
+
+		if assert.Error(t, err) {
+			fe := ferr.Infer(err)
+			assert.Equal(t, ferr.Code(ferr.CodeTimeout), fe.Code)
+			assert.Contains(t, fe.Message, "job")
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		err := Poll(context.Background(), &PollOpts{Interval: time.Millisecond, Timeout: 20 * time.Millisecond}, func(ctx context.Context) (bool, error) {
+			return false, nil
+		})
+
+		if assert.Error(t, err) {
+			assert.Equal(t, ferr.Code(ferr.CodeTimeout), ferr.Infer(err).Code)
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		}
+	})
+
+	t.Run("ctx cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		calls := 0
+
+		err := Poll(ctx, &PollOpts{Interval: time.Millisecond}, func(ctx context.Context) (bool, error) {
+			calls++
+			if calls == 2 {
+				cancel()
+			}
+
+			return false, nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NotEqual(t, ferr.Code(ferr.CodeTimeout), ferr.Infer(err).Code)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("checker error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+
+		err := Poll(context.Background(), nil, func(ctx context.Context) (bool, error) {
+			return false, errBoom
+		})
+
+		assert.ErrorIs(t, err, errBoom)
+	})
+}
+
+func TestRetryValue(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		calls := 0
+
+		value, err := RetryValue(context.Background(), &RetryPolicy{Interval: time.Millisecond}, func(ctx context.Context) (int, error) {
+			calls++
+			if calls < 3 {
+				return 0, errBoom
+			}
+
+			return 42, nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 42, value)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("max attempts", func(t *testing.T) {
+		calls := 0
+
+		_, err := RetryValue(context.Background(), &RetryPolicy{Interval: time.Millisecond, MaxAttempts: 4}, func(ctx context.Context) (int, error) {
+			calls++
+			return 0, errBoom
+		})
+
+		assert.ErrorIs(t, err, errBoom)
+		assert.Contains(t, err.Error(), "gave up after 4 attempts")
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("non retryable ferr.Error", func(t *testing.T) {
+		calls := 0
+
+		_, err := RetryValue(context.Background(), &RetryPolicy{Interval: time.Millisecond}, func(ctx context.Context) (int, error) {
+			calls++
+
+			fe := ferr.New(ferr.ETGeneric, ferr.CodeUnknown, "permanent")
+			fe.Retry = &ferr.ErrorRetryInfo{ShouldRetry: false}
+
+			return 0, fe
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("custom should retry", func(t *testing.T) {
+		calls := 0
+
+		err := Retry(context.Background(), &RetryPolicy{
+			Interval:    time.Millisecond,
+			ShouldRetry: func(err error) bool { return !errors.Is(err, errBoom) },
+		}, func(ctx context.Context) error {
+			calls++
+			return errBoom
+		})
+
+		assert.ErrorIs(t, err, errBoom)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("honours retry wait time", func(t *testing.T) {
+		calls := 0
+		started := time.Now()
+
+		// the policy interval would make the test time out, so the error's wait time must be used instead
+		err := Retry(context.Background(), &RetryPolicy{Interval: time.Hour}, func(ctx context.Context) error {
+			calls++
+			if calls == 1 {
+				return ferr.New(ferr.ETGeneric, ferr.CodeUnknown, "busy").WithRetry(20)
+			}
+
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, calls)
+		assert.GreaterOrEqual(t, time.Since(started), 20*time.Millisecond)
+		assert.Less(t, time.Since(started), 10*time.Second)
+	})
+
+	t.Run("ctx cancel", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := RetryValue(ctx, &RetryPolicy{Interval: time.Hour}, func(ctx context.Context) (int, error) {
+			return 0, errBoom
+		})
+
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,286 @@
+package fcthelp
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"math/big"
+	"math/bits"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	AlphabetLetters           = letterBytes
+	AlphabetAlphanumeric      = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	AlphabetLowerAlphanumeric = "0123456789abcdefghijklmnopqrstuvwxyz"
+	AlphabetNumeric           = "0123456789"
+
+	// AlphabetReadable leaves out characters that are easily confused (0/O, 1/I/L, U/V), useful for codes people type in
+	AlphabetReadable = "23456789ABCDEFGHJKMNPQRSTWXYZ"
+
+	// AlphabetCrockford is the base32 alphabet used by sortable ids
+	AlphabetCrockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+const (
+	// SortableIDSeparator separates the prefix of a sortable id from the encoded id
+	SortableIDSeparator = "_"
+
+	sortableIDEncodedLen = 26
+	sortableIDMaxTimeMS  = 1<<48 - 1
+)
+
+// SortableID is a 128 bit id made of a 48 bit millisecond timestamp followed by 80 random bits, with an optional prefix
+// the encoded id is ULID compatible, ids sort by their creation time (ids created in the same millisecond sort randomly)
+type SortableID struct {
+	Prefix string
+	Time   time.Time
+	Random [10]byte
+}
+
+// String encodes the id, eg. usr_01H8XGJWBWBAQ4Z4Y2G2R0FQKM
+func (id SortableID) String() string {
+	var raw [16]byte
+
+	binary.BigEndian.PutUint64(raw[:8], uint64(id.Time.UnixMilli())<<16)
+	copy(raw[6:], id.Random[:])
+
+	encoded := encodeCrockford(raw)
+
+	if id.Prefix == "" {
+		return encoded
+	}
+
+	return id.Prefix + SortableIDSeparator + encoded
+}
+
+// SecureRandString is RandString, using crypto/rand. It is safe to use for secrets and concurrently
+func SecureRandString(n int) (string, error) {
+	return SecureRandStringFromAlphabet(n, AlphabetLetters)
+}
+
+// SecureRandStringFromAlphabet generates a random string of n characters from alphabet using crypto/rand
+// alphabet must contain at least 2 unique ascii characters, every character is equally likely
+func SecureRandStringFromAlphabet(n int, alphabet string) (string, error) {
+	if err := validateAlphabet(alphabet); err != nil {
+		return "", err
+	}
+
+	// bytes are masked down to the smallest power of two that covers the alphabet,
+	// and values outside the alphabet are rejected, so that every character is equally likely
+	mask := byte(1<<bits.Len(uint(len(alphabet)-1)) - 1)
+
+	out := make([]byte, 0, n)
+	buf := make([]byte, n+n/2+8)
+
+	for len(out) < n {
+		if _, err := crand.Read(buf); err != nil {
+			return "", ferr.Wrap(err)
+		}
+
+		for _, b := range buf {
+			if idx := int(b & mask); idx < len(alphabet) {
+				out = append(out, alphabet[idx])
+
+				if len(out) == n {
+					break
+				}
+			}
+		}
+	}
+
+	return string(out), nil
+}
+
+// validateAlphabet rejects alphabets that would bias the output, duplicated characters would be picked more often
+// and multi-byte characters would be split into invalid bytes
+func validateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return ferr.InvalidArgument("alphabet", "must contain at least 2 characters")
+	}
+
+	var seen [utf8.RuneSelf]bool
+
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+
+		if c >= utf8.RuneSelf {
+			return ferr.InvalidArgument("alphabet", "must only contain ascii characters")
+		}
+
+		if seen[c] {
+			return ferr.InvalidArgument("alphabet", fmt.Sprintf("must not contain duplicate characters, %q is repeated", c))
+		}
+
+		seen[c] = true
+	}
+
+	return nil
+}
+
+// SecureRandFromSlice is RandFromSlice, using crypto/rand. It returns an error if the slice is empty
+func SecureRandFromSlice[T any](slice []T) (T, error) {
+	var zero T
+
+	if len(slice) == 0 {
+		return zero, ferr.InvalidArgument("slice", "cannot pick from an empty slice")
+	}
+
+	idx, err := crand.Int(crand.Reader, big.NewInt(int64(len(slice))))
+	if err != nil {
+		return zero, ferr.Wrap(err)
+	}
+
+	return slice[idx.Int64()], nil
+}
+
+// NewSortableID generates a sortable id for the current time, prefix may be empty
+// prefixes must start with a lowercase letter, and only contain lowercase letters and digits
+func NewSortableID(prefix string) (string, error) {
+	return NewSortableIDAt(prefix, time.Now())
+}
+
+// NewSortableIDAt is NewSortableID, with the timestamp set to t
+func NewSortableIDAt(prefix string, t time.Time) (string, error) {
+	if err := validateSortableIDPrefix(prefix); err != nil {
+		return "", err
+	}
+
+	ms := t.UnixMilli()
+	if ms < 0 || ms > sortableIDMaxTimeMS {
+		return "", ferr.InvalidArgument("time", "must be between 1970 and 10889")
+	}
+
+	id := SortableID{Prefix: prefix, Time: time.UnixMilli(ms)}
+
+	if _, err := crand.Read(id.Random[:]); err != nil {
+		return "", ferr.Wrap(err)
+	}
+
+	return id.String(), nil
+}
+
+// ParseSortableID decodes an id created by NewSortableID, decoding is case-insensitive
+func ParseSortableID(id string) (*SortableID, error) {
+	prefix, encoded := "", id
+
+	if idx := strings.LastIndex(id, SortableIDSeparator); idx >= 0 {
+		prefix, encoded = id[:idx], id[idx+len(SortableIDSeparator):]
+	}
+
+	if err := validateSortableIDPrefix(prefix); err != nil {
+		return nil, err
+	}
+
+	raw, err := decodeCrockford(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed := &SortableID{
+		Prefix: prefix,
+		Time:   time.UnixMilli(int64(binary.BigEndian.Uint64(raw[:8]) >> 16)),
+	}
+
+	copy(parsed.Random[:], raw[6:])
+
+	return parsed, nil
+}
+
+// ValidateSortableID checks that id is a valid sortable id with the given prefix
+func ValidateSortableID(id string, prefix string) error {
+	parsed, err := ParseSortableID(id)
+	if err != nil {
+		return err
+	}
+
+	if parsed.Prefix != prefix {
+		return ferr.InvalidArgument("id", fmt.Sprintf("expected prefix %q, got %q", prefix, parsed.Prefix))
+	}
+
+	return nil
+}
+
+func validateSortableIDPrefix(prefix string) error {
+	for i, r := range prefix {
+		isLower := r >= 'a' && r <= 'z'
+		isDigit := r >= '0' && r <= '9'
+
+		if !isLower && (i == 0 || !isDigit) {
+			return ferr.InvalidArgument("id", fmt.Sprintf("invalid prefix %q, must start with a lowercase letter and only contain lowercase letters and digits", prefix))
+		}
+	}
+
+	return nil
+}
+
+// encodeCrockford encodes 128 bits as 26 base32 characters of 5 bits each, the first character holds 2 padding bits
+func encodeCrockford(raw [16]byte) string {
+	out := make([]byte, sortableIDEncodedLen)
+
+	for i := range out {
+		var v int
+
+		for b := 0; b < 5; b++ {
+			bit := i*5 + b - 2
+			v <<= 1
+
+			if bit >= 0 && raw[bit/8]&(0x80>>(bit%8)) != 0 {
+				v |= 1
+			}
+		}
+
+		out[i] = AlphabetCrockford[v]
+	}
+
+	return string(out)
+}
+
+func decodeCrockford(encoded string) ([16]byte, error) {
+	var raw [16]byte
+
+	if len(encoded) != sortableIDEncodedLen {
+		return raw, ferr.InvalidArgument("id", fmt.Sprintf("expected %d characters, got %d", sortableIDEncodedLen, len(encoded)))
+	}
+
+	for i := 0; i < len(encoded); i++ {
+		v := crockfordValue(encoded[i])
+		if v < 0 {
+			return raw, ferr.InvalidArgument("id", fmt.Sprintf("invalid character %q", encoded[i]))
+		}
+
+		// the first character only holds 3 bits of the id
+		if i == 0 && v > 7 {
+			return raw, ferr.InvalidArgument("id", "timestamp is out of range")
+		}
+
+		for b := 0; b < 5; b++ {
+			bit := i*5 + b - 2
+
+			if bit >= 0 && v&(0x10>>b) != 0 {
+				raw[bit/8] |= 0x80 >> (bit % 8)
+			}
+		}
+	}
+
+	return raw, nil
+}
+
+// crockfordValue decodes a single base32 character, accepting lowercase and the commonly confused I, L and O
+func crockfordValue(c byte) int {
+	switch c {
+	case 'i', 'I', 'l', 'L':
+		return 1
+	case 'o', 'O':
+		return 0
+	}
+
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+
+	return strings.IndexByte(AlphabetCrockford, c)
+}
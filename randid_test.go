@@ -0,0 +1,112 @@
+package fcthelp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSecureRandStringFromAlphabet(t *testing.T) {
+	t.Parallel()
+
+	s, err := SecureRandString(32)
+	assert.NoError(t, err)
+	assert.Len(t, s, 32)
+
+	for _, c := range s {
+		assert.Contains(t, AlphabetLetters, string(c))
+	}
+
+	empty, err := SecureRandStringFromAlphabet(0, AlphabetNumeric)
+	assert.NoError(t, err)
+	assert.Empty(t, empty)
+
+	// every character of an alphabet that isn't a power of two in size is still used
+	s, err = SecureRandStringFromAlphabet(2000, AlphabetReadable)
+	assert.NoError(t, err)
+
+	for _, c := range AlphabetReadable {
+		assert.Contains(t, s, string(c))
+	}
+
+	for _, alphabet := range []string{"", "a", "abca", "abcé", "ab\x80"} {
+		_, err := SecureRandStringFromAlphabet(8, alphabet)
+		assert.Error(t, err, "alphabet %q", alphabet)
+	}
+}
+
+func TestSecureRandFromSlice(t *testing.T) {
+	t.Parallel()
+
+	item, err := SecureRandFromSlice([]string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Contains(t, []string{"a", "b"}, item)
+
+	_, err = SecureRandFromSlice([]string{})
+	assert.Error(t, err)
+
+	_, err = SecureRandFromSlice[int](nil)
+	assert.Error(t, err)
+}
+
+func TestSortableID(t *testing.T) {
+	t.Parallel()
+
+	at := time.Date(2023, 8, 1, 12, 30, 0, 123_000_000, time.UTC)
+
+	id, err := NewSortableIDAt("usr", at)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(id, "usr_"))
+	assert.Len(t, id, len("usr_")+26)
+
+	parsed, err := ParseSortableID(id)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "usr", parsed.Prefix)
+		assert.True(t, at.Equal(parsed.Time))
+		assert.Equal(t, id, parsed.String())
+	}
+
+	lower, err := ParseSortableID(strings.ToLower(id))
+	if assert.NoError(t, err) {
+		assert.Equal(t, parsed, lower)
+	}
+
+	assert.NoError(t, ValidateSortableID(id, "usr"))
+	assert.Error(t, ValidateSortableID(id, "org"))
+
+	unprefixed, err := NewSortableID("")
+	assert.NoError(t, err)
+	assert.Len(t, unprefixed, 26)
+	assert.NoError(t, ValidateSortableID(unprefixed, ""))
+
+	t.Run("ids sort by time", func(t *testing.T) {
+		var ids []string
+
+		for i := 0; i < 5; i++ {
+			id, err := NewSortableIDAt("evt", at.Add(time.Duration(i)*time.Millisecond))
+			assert.NoError(t, err)
+
+			ids = append(ids, id)
+		}
+
+		assert.True(t, sort.StringsAreSorted(ids))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := NewSortableIDAt("Usr", at)
+		assert.Error(t, err)
+
+		_, err = NewSortableIDAt("1usr", at)
+		assert.Error(t, err)
+
+		_, err = NewSortableIDAt("usr", time.UnixMilli(-1))
+		assert.Error(t, err)
+
+		for _, invalid := range []string{"usr_short", "usr_" + strings.Repeat("U", 26), "usr_8" + strings.Repeat("0", 25), "USR_" + id[4:]} {
+			_, err := ParseSortableID(invalid)
+			assert.Error(t, err, invalid)
+		}
+	})
+}
@@ -0,0 +1,158 @@
+package retokenizer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"time"
+)
+
+// APIKey is a stored API key, only the hash of the key itself is kept
+type APIKey struct {
+	ID   string `json:"id"`
+	Hash string `json:"hash"`
+
+	// User is the identity requests made with the key authenticate as
+	User *UserInfo `json:"user"`
+
+	// ExpiresAt is optional, keys without it are valid until deleted
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyStore looks up API keys by their hash, returning ErrInvalidAuthorization for unknown hashes
+type APIKeyStore interface {
+	LookupAPIKey(ctx context.Context, hash string) (*APIKey, error)
+}
+
+// APIKeyProvider is an AuthenticationProvider for API keys
+// keys are hashed with HMAC-SHA256 before they are looked up, so stores never see or keep the keys themselves
+type APIKeyProvider struct {
+	store  APIKeyStore
+	secret []byte
+}
+
+// NewAPIKeyProvider creates an APIKeyProvider
+// secret is the HMAC key used to hash API keys, without it a leaked store could be checked against guessed keys offline
+func NewAPIKeyProvider(store APIKeyStore, secret []byte) *APIKeyProvider {
+	return &APIKeyProvider{
+		store:  store,
+		secret: secret,
+	}
+}
+
+// GenerateAPIKey creates a new random API key for a user
+// the key is returned to be shown to the user once, and the APIKey, holding only its hash, is meant to be stored
+func (p *APIKeyProvider) GenerateAPIKey(user *UserInfo, expiresAt *time.Time) (string, *APIKey) {
+	key := randomToken(32)
+
+	return key, &APIKey{
+		ID:        randomToken(8),
+		Hash:      p.Hash(key),
+		User:      user,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// Hash hashes an API key the way it is stored
+func (p *APIKeyProvider) Hash(key string) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write([]byte(key))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *APIKeyProvider) GetUserInfo(ctx context.Context, key string) (*UserInfo, error) {
+	hash := p.Hash(key)
+
+	apiKey, err := p.store.LookupAPIKey(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAuthorization) {
+			return nil, err
+		}
+
+		return nil, ferr.Wrap(err)
+	}
+
+	// stores index by hash, this guards against ones that match loosely
+	if !hmac.Equal([]byte(apiKey.Hash), []byte(hash)) {
+		return nil, ErrInvalidAuthorization
+	}
+
+	if apiKey.ExpiresAt != nil && time.Now().After(*apiKey.ExpiresAt) {
+		return nil, ErrInvalidAuthorization
+	}
+
+	return apiKey.User, nil
+}
+
+// CacheAPIKeyStore is an APIKeyStore kept in a cache.Cache, the cache must not evict entries
+type CacheAPIKeyStore struct {
+	cache cache.Cache
+}
+
+func NewCacheAPIKeyStore(c cache.Cache) *CacheAPIKeyStore {
+	return &CacheAPIKeyStore{cache: c}
+}
+
+// StoreAPIKey adds or replaces an API key
+func (s *CacheAPIKeyStore) StoreAPIKey(ctx context.Context, apiKey *APIKey) error {
+	jsonBytes, err := json.Marshal(apiKey)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	if apiKey.ExpiresAt != nil {
+		err = s.cache.StoreValueWithExpiry(ctx, apiKeyCacheKey(apiKey.Hash), jsonBytes, time.Until(*apiKey.ExpiresAt))
+	} else {
+		err = s.cache.StoreValue(ctx, apiKeyCacheKey(apiKey.Hash), jsonBytes)
+	}
+
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+// DeleteAPIKey revokes an API key by its hash
+// pass the options of the middlewares accepting the key, so the users they cached for it are evicted right away
+func (s *CacheAPIKeyStore) DeleteAPIKey(ctx context.Context, hash string, middlewares ...*MiddlewareOptions) error {
+	err := s.cache.InvalidateValue(ctx, apiKeyCacheKey(hash))
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	for _, opts := range middlewares {
+		if err := opts.InvalidateCachedAPIKey(ctx, hash); err != nil {
+			return ferr.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (s *CacheAPIKeyStore) LookupAPIKey(ctx context.Context, hash string) (*APIKey, error) {
+	jsonBytes, err := s.cache.RetrieveValue(ctx, apiKeyCacheKey(hash))
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return nil, ErrInvalidAuthorization
+	} else if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	var apiKey APIKey
+
+	if err := json.Unmarshal(jsonBytes, &apiKey); err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return &apiKey, nil
+}
+
+func apiKeyCacheKey(hash string) string {
+	return "api-key:" + hash
+}
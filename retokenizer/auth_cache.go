@@ -0,0 +1,93 @@
+package retokenizer
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/golang-jwt/jwt/v4"
+	"time"
+)
+
+// InvalidateCachedToken removes the cached user of a token, and the internal token minted for it in exchange mode,
+// so the next request with it asks the provider again
+// meant for logout and revocation, opts must be the options the middleware was made with
+func (opts *MiddlewareOptions) InvalidateCachedToken(ctx context.Context, scheme, token string) error {
+	return opts.invalidateCachedHash(ctx, scheme, opts.tokenHash(&Credential{Scheme: scheme, Token: token}))
+}
+
+// InvalidateCachedAPIKey is InvalidateCachedToken for an API key known only by its hash, ie. one being deleted
+// it covers every scheme routed to an APIKeyProvider
+func (opts *MiddlewareOptions) InvalidateCachedAPIKey(ctx context.Context, hash string) error {
+	for scheme, provider := range opts.Providers {
+		if _, ok := provider.(*APIKeyProvider); !ok {
+			continue
+		}
+
+		if err := opts.invalidateCachedHash(ctx, scheme, hash); err != nil {
+			return ferr.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+func (opts *MiddlewareOptions) invalidateCachedHash(ctx context.Context, scheme, hash string) error {
+	if opts.Cache == nil {
+		return nil
+	}
+
+	for _, key := range []string{"auth:" + scheme + ":" + hash, "auth-internal:" + scheme + ":" + hash} {
+		if err := opts.Cache.InvalidateValue(ctx, key); err != nil {
+			return ferr.Wrap(err)
+		}
+	}
+
+	return nil
+}
+
+// authCacheKey builds the cache key of a credential from a keyed hash of the token
+func (opts *MiddlewareOptions) authCacheKey(credential *Credential) string {
+	return "auth:" + credential.Scheme + ":" + opts.tokenHash(credential)
+}
+
+// internalTokenCacheKey is the cache key of the internal token minted for a credential in exchange mode
+func (opts *MiddlewareOptions) internalTokenCacheKey(credential *Credential) string {
+	return "auth-internal:" + credential.Scheme + ":" + opts.tokenHash(credential)
+}
+
+// tokenHash hashes API keys the way their provider stores them, so entries can be evicted when a key is deleted
+// other tokens are hashed with CacheKeySecret
+func (opts *MiddlewareOptions) tokenHash(credential *Credential) string {
+	if provider, ok := opts.Providers[credential.Scheme].(*APIKeyProvider); ok {
+		return provider.Hash(credential.Token)
+	}
+
+	mac := hmac.New(sha256.New, opts.CacheKeySecret)
+	mac.Write([]byte(credential.Token))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// authCacheTTL is how long the user of a token may be cached, capped at the token's exp claim for JWTs
+func (opts *MiddlewareOptions) authCacheTTL(token string) time.Duration {
+	ttl := *opts.AuthCacheDuration
+
+	if !looksLikeJWT(token) {
+		return ttl
+	}
+
+	// the claims are only used to shorten the ttl, so the token doesn't need to be verified here
+	var claims jwt.RegisteredClaims
+
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil || claims.ExpiresAt == nil {
+		return ttl
+	}
+
+	if untilExpiry := time.Until(claims.ExpiresAt.Time); untilExpiry < ttl {
+		return untilExpiry
+	}
+
+	return ttl
+}
@@ -0,0 +1,88 @@
+package retokenizer
+
+import (
+	"context"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// keyRecordingCache records the keys values are stored under
+type keyRecordingCache struct {
+	*cache.InMemoryCache
+	keys []string
+}
+
+func (c *keyRecordingCache) StoreValueWithExpiry(ctx context.Context, key string, value []byte, expiresIn time.Duration) error {
+	c.keys = append(c.keys, key)
+	return c.InMemoryCache.StoreValueWithExpiry(ctx, key, value, expiresIn)
+}
+
+func TestAuthCache(t *testing.T) {
+	t.Parallel()
+
+	rt := New(&Opts{})
+	provider := &countingProvider{}
+	recorder := &keyRecordingCache{InMemoryCache: cache.NewInMemoryCache()}
+
+	opts := &MiddlewareOptions{
+		AuthProvider:   provider,
+		Cache:          recorder,
+		CacheKeySecret: []byte("cache-key-secret"),
+	}
+
+	app := fiber.New()
+	app.Get("/", rt.MakeFiberMiddleware(opts), func(c *fiber.Ctx) error {
+		return c.SendStatus(http.StatusOK)
+	})
+
+	do := func(token string) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	do("secret-upstream-token")
+	do("secret-upstream-token")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&provider.calls))
+
+	if assert.Len(t, recorder.keys, 1) {
+		assert.True(t, strings.HasPrefix(recorder.keys[0], "auth:bearer:"))
+		assert.NotContains(t, recorder.keys[0], "secret-upstream-token")
+	}
+
+	assert.NoError(t, opts.InvalidateCachedToken(context.Background(), SchemeBearer, "secret-upstream-token"))
+
+	do("secret-upstream-token")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&provider.calls))
+
+	t.Run("ttl is capped at the token's expiry", func(t *testing.T) {
+		key := []byte("upstream-key")
+
+		soon, _, err := CreateJWTForUser(key, &CreateJWTOpts{ExpiresIn: time.Minute}, "1", &UserInfo{})
+		assert.NoError(t, err)
+
+		later, _, err := CreateJWTForUser(key, &CreateJWTOpts{ExpiresIn: time.Hour}, "1", &UserInfo{})
+		assert.NoError(t, err)
+
+		expired, _, err := CreateJWTForUser(key, &CreateJWTOpts{ExpiresIn: -time.Minute}, "1", &UserInfo{})
+		assert.NoError(t, err)
+
+		assert.InDelta(t, time.Minute, opts.authCacheTTL(soon), float64(2*time.Second))
+		assert.Equal(t, DefaultAuthCacheDuration, opts.authCacheTTL(later))
+		assert.LessOrEqual(t, opts.authCacheTTL(expired), time.Duration(0))
+		assert.Equal(t, DefaultAuthCacheDuration, opts.authCacheTTL("opaque"))
+	})
+}
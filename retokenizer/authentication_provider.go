@@ -16,6 +16,12 @@ type UserInfo struct {
 	UpdatedAt     string `json:"updated_at"`
 	Email         string `json:"email"`
 	EmailVerified bool   `json:"email_verified"`
+
+	Roles  []string `json:"roles,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+
+	// Claims holds any other claims about the user, for use by policies
+	Claims map[string]any `json:"claims,omitempty"`
 }
 
 var ErrInvalidAuthorization = errors.New("invalid authorization")
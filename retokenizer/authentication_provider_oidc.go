@@ -0,0 +1,322 @@
+package retokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const oidcDiscoveryPath = "/.well-known/openid-configuration"
+
+// OIDCDiscovery is the subset of the issuer's openid-configuration document used by OIDCProvider
+type OIDCDiscovery struct {
+	Issuer           string `json:"issuer"`
+	JWKSURI          string `json:"jwks_uri"`
+	UserInfoEndpoint string `json:"userinfo_endpoint"`
+	TokenEndpoint    string `json:"token_endpoint"`
+}
+
+type OIDCProviderOpts struct {
+	// Issuer is the issuer url, the configuration is discovered at Issuer + /.well-known/openid-configuration
+	Issuer string
+
+	// Audience must be in the aud claim of access tokens if set
+	Audience string
+
+	// HTTPClient is used for every request to the issuer, defaults to a client with a 10 second timeout
+	HTTPClient *http.Client
+
+	// JWKSCacheDuration is how long keys are cached before being refetched, defaults to DefaultJWKSCacheDuration
+	JWKSCacheDuration time.Duration
+
+	// JWKSMinRefreshInterval limits how often an unknown key id causes the keys to be refetched,
+	// defaults to DefaultJWKSMinRefreshInterval
+	JWKSMinRefreshInterval time.Duration
+
+	// Leeway allows for clock skew when checking exp and nbf
+	Leeway time.Duration
+
+	// ClaimsMapper converts the claims of a verified token into a UserInfo, defaults to DefaultClaimsMapper
+	ClaimsMapper func(claims jwt.MapClaims) (*UserInfo, error)
+
+	// DisableUserInfoFallback rejects tokens that aren't JWTs, instead of asking the userinfo endpoint about them
+	DisableUserInfoFallback bool
+}
+
+// OIDCProvider is an AuthenticationProvider for tokens issued by an OpenID Connect issuer
+// JWT access tokens signed with RS256 or ES256 are verified locally against the issuer's JWKS,
+// and opaque tokens are sent to the issuer's userinfo endpoint
+type OIDCProvider struct {
+	opts      *OIDCProviderOpts
+	client    *http.Client
+	discovery OIDCDiscovery
+	keys      *remoteKeySet
+	parser    *jwt.Parser
+}
+
+// invalidTokenError is an ErrInvalidAuthorization that keeps the reason the token was rejected
+type invalidTokenError struct {
+	reason error
+}
+
+func (e *invalidTokenError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrInvalidAuthorization, e.reason)
+}
+
+func (e *invalidTokenError) Is(target error) bool {
+	return target == ErrInvalidAuthorization
+}
+
+func (e *invalidTokenError) Unwrap() error {
+	return e.reason
+}
+
+// NewOIDCProvider loads the issuer's openid-configuration, and returns a provider for its tokens
+func NewOIDCProvider(ctx context.Context, opts *OIDCProviderOpts) (*OIDCProvider, error) {
+	if opts.Issuer == "" {
+		return nil, ferr.MissingArgument("Issuer")
+	}
+
+	client := opts.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	issuer := strings.TrimSuffix(opts.Issuer, "/")
+
+	var discovery OIDCDiscovery
+
+	err := getJSON(ctx, client, issuer+oidcDiscoveryPath, "", &discovery)
+	if err != nil {
+		return nil, ferr.Wrapf(err, "failed to discover openid configuration for %s", issuer)
+	}
+
+	if strings.TrimSuffix(discovery.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("issuer mismatch, expected %s but the openid configuration is for %s", issuer, discovery.Issuer)
+	}
+
+	if discovery.JWKSURI == "" {
+		return nil, errors.New("openid configuration is missing jwks_uri")
+	}
+
+	keys := &remoteKeySet{
+		url:                discovery.JWKSURI,
+		client:             client,
+		cacheDuration:      opts.JWKSCacheDuration,
+		minRefreshInterval: opts.JWKSMinRefreshInterval,
+	}
+
+	if keys.cacheDuration <= 0 {
+		keys.cacheDuration = DefaultJWKSCacheDuration
+	}
+
+	if keys.minRefreshInterval <= 0 {
+		keys.minRefreshInterval = DefaultJWKSMinRefreshInterval
+	}
+
+	return &OIDCProvider{
+		opts:      opts,
+		client:    client,
+		discovery: discovery,
+		keys:      keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Name, jwt.SigningMethodES256.Name}),
+			jwt.WithLeeway(opts.Leeway),
+		),
+	}, nil
+}
+
+// Discovery returns the issuer's openid-configuration
+func (p *OIDCProvider) Discovery() OIDCDiscovery {
+	return p.discovery
+}
+
+func (p *OIDCProvider) GetUserInfo(ctx context.Context, token string) (*UserInfo, error) {
+	if !looksLikeJWT(token) {
+		return p.fetchUserInfo(ctx, token)
+	}
+
+	claims, err := p.VerifyToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper := p.opts.ClaimsMapper
+	if mapper == nil {
+		mapper = DefaultClaimsMapper
+	}
+
+	userInfo, err := mapper(claims)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return userInfo, nil
+}
+
+// VerifyToken checks the signature, issuer, audience and expiry of a JWT access token, and returns its claims
+// rejected tokens return an error matching ErrInvalidAuthorization
+func (p *OIDCProvider) VerifyToken(ctx context.Context, token string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := p.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	})
+	if err != nil {
+		var ksErr *keySetError
+		if errors.As(err, &ksErr) {
+			return nil, ferr.Wrap(ksErr)
+		}
+
+		return nil, &invalidTokenError{reason: err}
+	}
+
+	if !claims.VerifyIssuer(p.discovery.Issuer, true) {
+		return nil, &invalidTokenError{reason: jwt.ErrTokenInvalidIssuer}
+	}
+
+	if p.opts.Audience != "" && !claims.VerifyAudience(p.opts.Audience, true) {
+		return nil, &invalidTokenError{reason: jwt.ErrTokenInvalidAudience}
+	}
+
+	if _, ok := claims["exp"]; !ok {
+		return nil, &invalidTokenError{reason: errors.New("token has no expiry")}
+	}
+
+	return claims, nil
+}
+
+func (p *OIDCProvider) fetchUserInfo(ctx context.Context, token string) (*UserInfo, error) {
+	if p.opts.DisableUserInfoFallback || p.discovery.UserInfoEndpoint == "" {
+		return nil, &invalidTokenError{reason: jwt.ErrTokenMalformed}
+	}
+
+	var claims jwt.MapClaims
+
+	err := getJSON(ctx, p.client, p.discovery.UserInfoEndpoint, token, &claims)
+	if err != nil {
+		if errors.Is(err, ErrInvalidAuthorization) {
+			return nil, err
+		}
+
+		return nil, ferr.Wrap(err)
+	}
+
+	return DefaultClaimsMapper(claims)
+}
+
+// DefaultClaimsMapper maps the standard OpenID Connect claims into a UserInfo
+// updated_at may be a unix timestamp or a string, and email_verified may be a bool or a string
+// roles are read from the roles claim, and scopes from scope or scp, as a space separated string or a list
+// every other claim is kept in Claims, for use by policies
+func DefaultClaimsMapper(claims jwt.MapClaims) (*UserInfo, error) {
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return nil, &invalidTokenError{reason: errors.New("token has no subject")}
+	}
+
+	str := func(key string) string {
+		s, _ := claims[key].(string)
+		return s
+	}
+
+	userInfo := &UserInfo{
+		Sub:        sub,
+		GivenName:  str("given_name"),
+		FamilyName: str("family_name"),
+		Nickname:   str("nickname"),
+		Name:       str("name"),
+		Picture:    str("picture"),
+		Locale:     str("locale"),
+		Email:      str("email"),
+	}
+
+	switch updatedAt := claims["updated_at"].(type) {
+	case float64:
+		userInfo.UpdatedAt = time.Unix(int64(updatedAt), 0).UTC().Format(time.RFC3339)
+	case json.Number:
+		if seconds, err := updatedAt.Int64(); err == nil {
+			userInfo.UpdatedAt = time.Unix(seconds, 0).UTC().Format(time.RFC3339)
+		}
+	case string:
+		userInfo.UpdatedAt = updatedAt
+	}
+
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		userInfo.EmailVerified = verified
+	case string:
+		userInfo.EmailVerified = verified == "true"
+	}
+
+	userInfo.Roles = stringList(claims["roles"])
+
+	if scope, ok := claims["scope"]; ok {
+		userInfo.Scopes = stringList(scope)
+	} else {
+		userInfo.Scopes = stringList(claims["scp"])
+	}
+
+	for name, value := range claims {
+		if mappedClaims[name] {
+			continue
+		}
+
+		if userInfo.Claims == nil {
+			userInfo.Claims = map[string]any{}
+		}
+
+		userInfo.Claims[name] = value
+	}
+
+	return userInfo, nil
+}
+
+// mappedClaims are the claims DefaultClaimsMapper stores in the fields of UserInfo
+var mappedClaims = map[string]bool{
+	"sub":            true,
+	"given_name":     true,
+	"family_name":    true,
+	"nickname":       true,
+	"name":           true,
+	"picture":        true,
+	"locale":         true,
+	"email":          true,
+	"email_verified": true,
+	"updated_at":     true,
+	"roles":          true,
+	"scope":          true,
+	"scp":            true,
+}
+
+// stringList reads a claim that is either a space separated string or a list of strings
+func stringList(claim any) []string {
+	switch v := claim.(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		list := make([]string, 0, len(v))
+
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+
+		return list
+	}
+
+	return nil
+}
+
+// looksLikeJWT is true for tokens made of three dot separated parts, anything else is treated as an opaque token
+func looksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
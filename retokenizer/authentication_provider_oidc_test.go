@@ -0,0 +1,231 @@
+package retokenizer
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeIssuer is an in-process OpenID Connect issuer
+type fakeIssuer struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]crypto.Signer
+	opaqueUsers map[string]map[string]any
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	fi := &fakeIssuer{keys: map[string]crypto.Signer{}, opaqueUsers: map[string]map[string]any{}}
+
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(oidcDiscoveryPath, func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(OIDCDiscovery{
+			Issuer:           fi.server.URL,
+			JWKSURI:          fi.server.URL + "/jwks",
+			UserInfoEndpoint: fi.server.URL + "/userinfo",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		fi.mu.Lock()
+		defer fi.mu.Unlock()
+
+		var set JSONWebKeySet
+
+		for kid, key := range fi.keys {
+			set.Keys = append(set.Keys, testJWK(kid, key.Public()))
+		}
+
+		_ = json.NewEncoder(w).Encode(set)
+	})
+
+	mux.HandleFunc("/userinfo", func(w http.ResponseWriter, r *http.Request) {
+		fi.mu.Lock()
+		user, ok := fi.opaqueUsers[r.Header.Get("Authorization")]
+		fi.mu.Unlock()
+
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(user)
+	})
+
+	fi.server = httptest.NewServer(mux)
+	t.Cleanup(fi.server.Close)
+
+	return fi
+}
+
+func (fi *fakeIssuer) addKey(kid string, key crypto.Signer) {
+	fi.mu.Lock()
+	defer fi.mu.Unlock()
+
+	fi.keys[kid] = key
+}
+
+func (fi *fakeIssuer) sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.MapClaims) string {
+	fi.mu.Lock()
+	key := fi.keys[kid]
+	fi.mu.Unlock()
+
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed
+}
+
+func (fi *fakeIssuer) claims(sub string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            fi.server.URL,
+		"aud":            "api",
+		"sub":            sub,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"email":          sub + "@example.com",
+		"email_verified": true,
+		"updated_at":     1650000000,
+	}
+}
+
+func testJWK(kid string, public crypto.PublicKey) JSONWebKey {
+	enc := func(i *big.Int) string {
+		return base64.RawURLEncoding.EncodeToString(i.Bytes())
+	}
+
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{Kty: "RSA", Kid: kid, Use: "sig", N: enc(key.N), E: enc(big.NewInt(int64(key.E)))}
+	case *ecdsa.PublicKey:
+		return JSONWebKey{Kty: "EC", Kid: kid, Use: "sig", Crv: key.Curve.Params().Name, X: enc(key.X), Y: enc(key.Y)}
+	}
+
+	panic("unsupported key")
+}
+
+func TestOIDCProvider(t *testing.T) {
+	t.Parallel()
+
+	fi := newFakeIssuer(t)
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	fi.addKey("rsa-1", rsaKey)
+	fi.addKey("ec-1", ecKey)
+
+	provider, err := NewOIDCProvider(context.Background(), &OIDCProviderOpts{
+		Issuer:                 fi.server.URL,
+		Audience:               "api",
+		JWKSMinRefreshInterval: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+
+	t.Run("RS256 and ES256", func(t *testing.T) {
+		user, err := provider.GetUserInfo(ctx, fi.sign(t, jwt.SigningMethodRS256, "rsa-1", fi.claims("alice")))
+		assert.NoError(t, err)
+		assert.Equal(t, "alice", user.Sub)
+		assert.Equal(t, "alice@example.com", user.Email)
+		assert.True(t, user.EmailVerified)
+		assert.Equal(t, "2022-04-15T05:20:00Z", user.UpdatedAt)
+
+		user, err = provider.GetUserInfo(ctx, fi.sign(t, jwt.SigningMethodES256, "ec-1", fi.claims("bob")))
+		assert.NoError(t, err)
+		assert.Equal(t, "bob", user.Sub)
+	})
+
+	t.Run("other claims are kept for policies", func(t *testing.T) {
+		claims := fi.claims("frank")
+		claims["org"] = "acme"
+		claims["roles"] = []string{"admin"}
+
+		user, err := provider.GetUserInfo(ctx, fi.sign(t, jwt.SigningMethodRS256, "rsa-1", claims))
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, user.Roles)
+		assert.Equal(t, "acme", user.Claim("org"))
+		assert.Equal(t, "api", user.Claim("aud"))
+		assert.Nil(t, user.Claim("email"))
+		assert.Nil(t, user.Claim("roles"))
+
+		sameOrg := RequirePolicy("org:member", func(_ *fiber.Ctx, user *UserInfo) (bool, error) {
+			return user.Claim("org") == "acme", nil
+		})
+
+		missing, err := sameOrg(nil, user)
+		assert.NoError(t, err)
+		assert.Empty(t, missing)
+	})
+
+	t.Run("key rotation", func(t *testing.T) {
+		rotated, _ := rsa.GenerateKey(rand.Reader, 2048)
+		fi.addKey("rsa-2", rotated)
+
+		user, err := provider.GetUserInfo(ctx, fi.sign(t, jwt.SigningMethodRS256, "rsa-2", fi.claims("carol")))
+		assert.NoError(t, err)
+		assert.Equal(t, "carol", user.Sub)
+	})
+
+	t.Run("rejected tokens", func(t *testing.T) {
+		expired := fi.claims("dave")
+		expired["exp"] = time.Now().Add(-time.Minute).Unix()
+
+		_, err := provider.GetUserInfo(ctx, fi.sign(t, jwt.SigningMethodRS256, "rsa-1", expired))
+		assert.True(t, errors.Is(err, ErrInvalidAuthorization))
+		assert.True(t, errors.Is(err, jwt.ErrTokenExpired))
+
+		wrongAudience := fi.claims("dave")
+		wrongAudience["aud"] = "other"
+
+		_, err = provider.GetUserInfo(ctx, fi.sign(t, jwt.SigningMethodRS256, "rsa-1", wrongAudience))
+		assert.True(t, errors.Is(err, ErrInvalidAuthorization))
+
+		// signed by a key the issuer never published
+		unknownKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, fi.claims("mallory"))
+		token.Header["kid"] = "rsa-1"
+		forged, _ := token.SignedString(unknownKey)
+
+		_, err = provider.GetUserInfo(ctx, forged)
+		assert.True(t, errors.Is(err, ErrInvalidAuthorization))
+	})
+
+	t.Run("opaque tokens use userinfo", func(t *testing.T) {
+		fi.mu.Lock()
+		fi.opaqueUsers["Bearer opaque-token"] = map[string]any{"sub": "erin", "email_verified": "true"}
+		fi.mu.Unlock()
+
+		user, err := provider.GetUserInfo(ctx, "opaque-token")
+		assert.NoError(t, err)
+		assert.Equal(t, "erin", user.Sub)
+		assert.True(t, user.EmailVerified)
+
+		_, err = provider.GetUserInfo(ctx, "unknown-token")
+		assert.True(t, errors.Is(err, ErrInvalidAuthorization))
+	})
+}
@@ -7,6 +7,7 @@ import (
 )
 
 // PlaceholderAuthenticationProvider will take any string as a token, and generate a random user
+// it accepts every token, so tests of rejected tokens should use retokenizertest.FakeProvider instead
 type PlaceholderAuthenticationProvider struct{}
 
 func (p *PlaceholderAuthenticationProvider) GetUserInfo(_ context.Context, token string) (*UserInfo, error) {
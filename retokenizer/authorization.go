@@ -0,0 +1,165 @@
+package retokenizer
+
+import (
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+// Guard checks whether the user of a request is allowed to continue
+// it returns the permissions the user is missing, which is empty when the user is allowed
+type Guard func(c *fiber.Ctx, user *UserInfo) ([]string, error)
+
+// PolicyFunc decides whether a user may access a resource, for checks that depend on the request
+// ie. comparing c.Params("orgID") with the orgs of the user
+type PolicyFunc func(c *fiber.Ctx, user *UserInfo) (bool, error)
+
+// HasRole checks whether the user has a role
+func (u *UserInfo) HasRole(role string) bool {
+	return contains(u.Roles, role)
+}
+
+// HasScope checks whether the user was granted a scope
+func (u *UserInfo) HasScope(scope string) bool {
+	return contains(u.Scopes, scope)
+}
+
+// Claim returns a custom claim of the user, or nil if it isn't set
+func (u *UserInfo) Claim(name string) any {
+	return u.Claims[name]
+}
+
+// Authorize returns a handler that continues only if the user attached by the auth middleware passes every guard
+// requests without a user fail with ferr.Unauthenticated, and users failing a guard with ferr.MissingPermissions
+// missing scopes are also reported in an insufficient_scope WWW-Authenticate challenge
+// ie. app.Delete("/orgs/:orgID", rt.MakeFiberMiddleware(opts), Authorize(RequireRole("admin")), handler)
+func Authorize(guards ...Guard) fiber.Handler {
+	guard := RequireAll(guards...)
+
+	return func(c *fiber.Ctx) error {
+		user := ExtractUserInfo(c.UserContext())
+		if user == nil {
+			return ferr.Unauthenticated
+		}
+
+		missing, err := guard(c, user)
+		if err != nil {
+			return ferr.Wrap(err)
+		}
+
+		if len(missing) > 0 {
+			var scopes []string
+
+			for _, permission := range missing {
+				if strings.HasPrefix(permission, "scope:") {
+					scopes = append(scopes, strings.TrimPrefix(permission, "scope:"))
+				}
+			}
+
+			if len(scopes) > 0 {
+				c.Set(fiber.HeaderWWWAuthenticate, insufficientScopeChallenge(scopes))
+			}
+
+			return ferr.MissingPermissions(missing...)
+		}
+
+		return c.Next()
+	}
+}
+
+// RequireScopes requires the user to have every scope, missing scopes are listed as scope:<name>
+func RequireScopes(scopes ...string) Guard {
+	return func(_ *fiber.Ctx, user *UserInfo) ([]string, error) {
+		var missing []string
+
+		for _, scope := range scopes {
+			if !user.HasScope(scope) {
+				missing = append(missing, "scope:"+scope)
+			}
+		}
+
+		return missing, nil
+	}
+}
+
+// RequireRole requires the user to have a role, which is listed as role:<name> if missing
+func RequireRole(role string) Guard {
+	return func(_ *fiber.Ctx, user *UserInfo) ([]string, error) {
+		if user.HasRole(role) {
+			return nil, nil
+		}
+
+		return []string{"role:" + role}, nil
+	}
+}
+
+// RequirePolicy requires a policy to allow the user, name is listed as the missing permission if it doesn't
+func RequirePolicy(name string, policy PolicyFunc) Guard {
+	return func(c *fiber.Ctx, user *UserInfo) ([]string, error) {
+		allowed, err := policy(c, user)
+		if err != nil {
+			return nil, ferr.Wrap(err)
+		}
+
+		if allowed {
+			return nil, nil
+		}
+
+		return []string{name}, nil
+	}
+}
+
+// RequireAll requires every guard to pass, listing everything that is missing
+func RequireAll(guards ...Guard) Guard {
+	return func(c *fiber.Ctx, user *UserInfo) ([]string, error) {
+		var missing []string
+
+		for _, guard := range guards {
+			guardMissing, err := guard(c, user)
+			if err != nil {
+				return nil, ferr.Wrap(err)
+			}
+
+			missing = append(missing, guardMissing...)
+		}
+
+		return missing, nil
+	}
+}
+
+// RequireAny requires at least one guard to pass, listing what each of them is missing if none do
+// it panics without guards, since there would be nothing for the user to pass
+func RequireAny(guards ...Guard) Guard {
+	if len(guards) == 0 {
+		panic("retokenizer: RequireAny needs at least one guard")
+	}
+
+	return func(c *fiber.Ctx, user *UserInfo) ([]string, error) {
+		var missing []string
+
+		for _, guard := range guards {
+			guardMissing, err := guard(c, user)
+			if err != nil {
+				return nil, ferr.Wrap(err)
+			}
+
+			if len(guardMissing) == 0 {
+				return nil, nil
+			}
+
+			missing = append(missing, guardMissing...)
+		}
+
+		return missing, nil
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
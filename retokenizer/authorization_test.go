@@ -0,0 +1,92 @@
+package retokenizer
+
+import (
+	"context"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	t.Parallel()
+
+	user := &UserInfo{
+		Sub:    "1",
+		Roles:  []string{"member"},
+		Scopes: []string{"orgs:read"},
+		Claims: map[string]any{"org": "acme"},
+	}
+
+	sameOrg := RequirePolicy("org:member", func(c *fiber.Ctx, user *UserInfo) (bool, error) {
+		return user.Claim("org") == c.Params("org"), nil
+	})
+
+	app := fiber.New()
+
+	app.Use(ferr.Middleware(false))
+	app.Use(func(c *fiber.Ctx) error {
+		if c.Get("X-Anonymous") == "" {
+			c.SetUserContext(context.WithValue(c.UserContext(), UserInfoContextKey, user))
+		}
+
+		return c.Next()
+	})
+
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(http.StatusOK) }
+
+	app.Get("/orgs/:org", Authorize(RequireScopes("orgs:read"), sameOrg), ok)
+	app.Delete("/orgs/:org", Authorize(RequireAny(RequireRole("admin"), RequireAll(RequireRole("owner"), sameOrg))), ok)
+
+	status := func(method, target string, anonymous bool) int {
+		req := httptest.NewRequest(method, target, nil)
+		if anonymous {
+			req.Header.Set("X-Anonymous", "1")
+		}
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, status(http.MethodGet, "/orgs/acme", false))
+	assert.Equal(t, http.StatusForbidden, status(http.MethodGet, "/orgs/other", false))
+	assert.Equal(t, http.StatusUnauthorized, status(http.MethodGet, "/orgs/acme", true))
+	assert.Equal(t, http.StatusForbidden, status(http.MethodDelete, "/orgs/acme", false))
+
+	user.Roles = append(user.Roles, "owner")
+	assert.Equal(t, http.StatusOK, status(http.MethodDelete, "/orgs/acme", false))
+
+	t.Run("missing permissions are listed", func(t *testing.T) {
+		missing, err := RequireAny(RequireRole("admin"), RequireScopes("orgs:write"))(nil, user)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"role:admin", "scope:orgs:write"}, missing)
+
+		assert.Panics(t, func() { RequireAny() })
+
+		var authErr error
+
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			c.SetUserContext(context.WithValue(c.UserContext(), UserInfoContextKey, user))
+			authErr = Authorize(RequireScopes("orgs:read", "orgs:write"))(c)
+
+			return nil
+		})
+
+		_, err = app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.NoError(t, err)
+
+		var fctErr *ferr.Error
+		if assert.True(t, errors.As(authErr, &fctErr)) {
+			assert.Equal(t, ferr.Code(ferr.CodeMissingPermissions), fctErr.Code)
+			assert.Equal(t, []string{"scope:orgs:write"}, fctErr.Detail)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package retokenizer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"strings"
+)
+
+// respondError rejects a request, setting a WWW-Authenticate challenge for authentication errors
+// the error is returned for ferr.Middleware to render, unless opts.LegacyErrorBodies is set
+func (opts *MiddlewareOptions) respondError(c *fiber.Ctx, err error) error {
+	if challenge := bearerChallenge(opts.Realm, err); challenge != "" {
+		c.Set(fiber.HeaderWWWAuthenticate, challenge)
+	}
+
+	if opts.LegacyErrorBodies {
+		status, body := legacyError(err)
+		return c.Status(status).JSON(body)
+	}
+
+	return err
+}
+
+// legacyError maps the errors returned by the middleware to the status and body it responded with before using ferr
+func legacyError(err error) (int, fiber.Map) {
+	switch ferr.Infer(err).Code {
+	case ferr.CodeMissingToken:
+		return http.StatusUnauthorized, ErrMissingTokenMsg
+	case ferr.CodeMalformedToken:
+		return http.StatusUnauthorized, ErrMalformedTokenMsg
+	case ferr.CodeTokenExpired, ferr.CodeInvalidToken:
+		return http.StatusUnauthorized, ErrInvalidAuthorizationMsg
+	}
+
+	return http.StatusInternalServerError, ErrInternalServerErrMsg
+}
+
+// bearerChallenge builds the WWW-Authenticate header for an authentication error, as described in RFC 6750
+// an empty string is returned for errors that aren't about the token
+func bearerChallenge(realm string, err error) string {
+	fctErr := ferr.Infer(err)
+
+	var params []string
+
+	if realm != "" {
+		params = append(params, fmt.Sprintf("realm=%q", realm))
+	}
+
+	switch fctErr.Code {
+	case ferr.CodeMissingToken:
+	case ferr.CodeMalformedToken:
+		params = append(params, `error="invalid_request"`, fmt.Sprintf("error_description=%q", fctErr.Message))
+	case ferr.CodeTokenExpired, ferr.CodeInvalidToken:
+		params = append(params, `error="invalid_token"`, fmt.Sprintf("error_description=%q", fctErr.Message))
+	default:
+		return ""
+	}
+
+	if len(params) == 0 {
+		return "Bearer"
+	}
+
+	return "Bearer " + strings.Join(params, ", ")
+}
+
+// insufficientScopeChallenge builds the WWW-Authenticate header for a user missing scopes, as described in RFC 6750
+func insufficientScopeChallenge(scopes []string) string {
+	return fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, strings.Join(scopes, " "))
+}
+
+// tokenError converts the errors from bearerToken into the error the middleware responds with
+func tokenError(err error) error {
+	if err == errMissingToken {
+		return ferr.MissingToken
+	}
+
+	return ferr.MalformedToken
+}
+
+// isTokenExpired is true for tokens that would have been accepted if they hadn't expired
+func isTokenExpired(err error) bool {
+	var validationErr *jwt.ValidationError
+
+	return errors.As(err, &validationErr) && validationErr.Errors == jwt.ValidationErrorExpired
+}
+
+// internalError is returned for failures that aren't the client's fault, the cause is only logged
+func internalError() error {
+	return ferr.InternalServerError("authentication")
+}
@@ -0,0 +1,221 @@
+package retokenizer
+
+import (
+	"context"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// DefaultInternalTokenLifetime is how long internal tokens minted by exchange mode are valid
+var DefaultInternalTokenLifetime = 5 * time.Minute
+
+var InternalTokenContextKey = ContextKey("__retokenizer_internal_token_context_key")
+
+// ExchangeOptions enables exchange mode in the middleware
+// upstream tokens are validated with the AuthenticationProvider once, and swapped for a short-lived internal JWT
+// carrying the UserInfo. Internal tokens are verified locally, without calling the provider
+type ExchangeOptions struct {
+	// Issuer is set on minted tokens, only internal tokens from this issuer are accepted
+	Issuer string
+
+	// Audience is set on minted tokens, when set only internal tokens for this audience are accepted
+	Audience string
+
+	// ExpiresIn is how long minted tokens are valid, defaults to DefaultInternalTokenLifetime
+	ExpiresIn time.Duration
+
+	// Refresh makes the exchange endpoint also issue refresh tokens, see MakeRefreshHandler
+	Refresh *RefreshTokenStore
+
+	// Revocations is checked for every internal token, see MakeLogoutHandler
+	Revocations *RevocationList
+}
+
+// ExchangeResponse is returned by the exchange endpoint, following the shape of an OAuth token response
+type ExchangeResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+func (eo *ExchangeOptions) jwtOpts() *CreateJWTOpts {
+	expiresIn := eo.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = DefaultInternalTokenLifetime
+	}
+
+	return &CreateJWTOpts{
+		Audience:  eo.Audience,
+		Issuer:    eo.Issuer,
+		ExpiresIn: expiresIn,
+	}
+}
+
+func (eo *ExchangeOptions) validateOpts(ctx context.Context) *ValidateJWTOpts {
+	return &ValidateJWTOpts{
+		Audience:    eo.Audience,
+		Revocations: eo.Revocations,
+		Context:     ctx,
+	}
+}
+
+// CreateInternalToken mints an internal JWT for a user, signed with the ReTokenizer's key set or signing key
+func (rt *ReTokenizer) CreateInternalToken(userInfo *UserInfo, opts *CreateJWTOpts) (string, *JWTClaims[UserInfo], error) {
+	if rt.keySet != nil {
+		return CreateJWTForUserWithKeySet(rt.keySet, opts, userInfo.Sub, userInfo)
+	}
+
+	return CreateJWTForUser(rt.key, opts, userInfo.Sub, userInfo)
+}
+
+// internalTokenFor returns the internal token the middleware attaches for a user authenticated with an upstream credential
+// minted tokens are cached next to the auth result, and reused while at least half of their lifetime is left
+func (rt *ReTokenizer) internalTokenFor(ctx context.Context, opts *MiddlewareOptions, credential *Credential, userInfo *UserInfo) (string, error) {
+	jwtOpts := opts.Exchange.jwtOpts()
+
+	mint := func(ctx context.Context) ([]byte, error) {
+		token, _, err := rt.CreateInternalToken(userInfo, jwtOpts)
+		if err != nil {
+			return nil, ferr.Wrap(err)
+		}
+
+		return []byte(token), nil
+	}
+
+	ttl := jwtOpts.ExpiresIn / 2
+	if authTTL := opts.authCacheTTL(credential.Token); authTTL < ttl {
+		ttl = authTTL
+	}
+
+	if ttl <= 0 {
+		token, err := mint(ctx)
+		return string(token), err
+	}
+
+	token, err := cache.GetCachedValueWithExpiry(ctx, opts.Cache, opts.internalTokenCacheKey(credential), mint, &ttl)
+	if err != nil {
+		return "", ferr.Wrap(err)
+	}
+
+	return string(token), nil
+}
+
+// ValidateInternalToken verifies an internal JWT minted by CreateInternalToken, and returns its user
+func (rt *ReTokenizer) ValidateInternalToken(token string, issuer string, opts ...*ValidateJWTOpts) (*UserInfo, error) {
+	if rt.keySet != nil {
+		return ValidateUserJWTWithKeySet[UserInfo](rt.keySet, token, issuer, opts...)
+	}
+
+	return ValidateUserJWT[UserInfo](rt.key, token, issuer, opts...)
+}
+
+// MakeExchangeHandler returns an endpoint that swaps an upstream credential, found by opts.Extractors, for an internal token
+// clients can then send the internal token until it expires, which the middleware verifies locally
+// opts.Exchange must be set
+func (rt *ReTokenizer) MakeExchangeHandler(opts *MiddlewareOptions) func(c *fiber.Ctx) error {
+	if opts.Exchange == nil {
+		panic("missing exchange options")
+	}
+
+	opts = rt.withMiddlewareDefaults(opts)
+
+	return func(c *fiber.Ctx) error {
+		spanCtx, span := rt.tracer.Start(c.UserContext(), "retokenizer-exchange")
+		defer span.End()
+
+		credential, err := extractCredential(c, opts.Extractors)
+		if err != nil {
+			return opts.respondError(c, tokenError(err))
+		}
+
+		userInfo, err := rt.authenticate(spanCtx, opts, credential)
+		if err != nil {
+			return opts.respondError(c, err)
+		}
+
+		var res *ExchangeResponse
+
+		if opts.Exchange.Refresh != nil {
+			res, err = rt.IssueTokenPair(spanCtx, userInfo, opts.Exchange)
+		} else {
+			res, err = rt.exchangeResponse(userInfo, opts.Exchange)
+		}
+
+		if err != nil {
+			opts.Logger.Error("failed to mint internal token", zap.Error(err))
+
+			return opts.respondError(c, internalError())
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		return c.JSON(res)
+	}
+}
+
+func (rt *ReTokenizer) exchangeResponse(userInfo *UserInfo, opts *ExchangeOptions) (*ExchangeResponse, error) {
+	internalToken, claims, err := rt.CreateInternalToken(userInfo, opts.jwtOpts())
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return &ExchangeResponse{
+		AccessToken: internalToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(claims.ExpiresAt.Time).Seconds()),
+	}, nil
+}
+
+// ExtractInternalToken returns the internal token attached by the middleware in exchange mode
+// meant to be called with fiber's UserContext
+// ie. ExtractInternalToken(c.UserContext())
+func ExtractInternalToken(ctx context.Context) string {
+	if v, ok := ctx.Value(InternalTokenContextKey).(string); ok {
+		return v
+	}
+
+	return ""
+}
+
+// ForwardInternalToken sets the internal token from ctx as the bearer token of a request to a downstream service
+// requests are left unchanged if ctx has no internal token
+func ForwardInternalToken(ctx context.Context, req *http.Request) {
+	if token := ExtractInternalToken(ctx); token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+}
+
+// ForwardingTransport is an http.RoundTripper that forwards the internal token from each request's context
+type ForwardingTransport struct {
+	// Base makes the actual requests, defaults to http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *ForwardingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	token := ExtractInternalToken(req.Context())
+	if token == "" {
+		return base.RoundTrip(req)
+	}
+
+	// RoundTrippers must not modify the request they are given
+	forwarded := req.Clone(req.Context())
+	forwarded.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+	res, err := base.RoundTrip(forwarded)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return res, nil
+}
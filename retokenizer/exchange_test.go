@@ -0,0 +1,133 @@
+package retokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingProvider counts calls to the PlaceholderAuthenticationProvider
+type countingProvider struct {
+	PlaceholderAuthenticationProvider
+	calls int32
+}
+
+func (p *countingProvider) GetUserInfo(ctx context.Context, token string) (*UserInfo, error) {
+	atomic.AddInt32(&p.calls, 1)
+	return p.PlaceholderAuthenticationProvider.GetUserInfo(ctx, token)
+}
+
+func TestExchangeMode(t *testing.T) {
+	t.Parallel()
+
+	rt := New(&Opts{JWTSigningKey: []byte("internal-signing-key")})
+	provider := &countingProvider{}
+
+	opts := &MiddlewareOptions{
+		AuthProvider: provider,
+		Exchange:     &ExchangeOptions{Issuer: "fct-internal", Audience: "services"},
+	}
+
+	app := fiber.New()
+	app.Use(ferr.Middleware(false))
+	app.Post("/auth/exchange", rt.MakeExchangeHandler(opts))
+	app.Get("/me", rt.MakeFiberMiddleware(opts), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"sub":            ExtractUserInfo(c.UserContext()).Sub,
+			"internal_token": ExtractInternalToken(c.UserContext()),
+		})
+	})
+
+	do := func(method, target, token string, out any) int {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(out))
+
+		return res.StatusCode
+	}
+
+	var me map[string]string
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/me", "upstream", &me))
+	assert.Equal(t, "upstream", me["sub"])
+	assert.NotEmpty(t, me["internal_token"])
+
+	user, err := rt.ValidateInternalToken(me["internal_token"], "fct-internal")
+	assert.NoError(t, err)
+	assert.Equal(t, "upstream", user.Sub)
+
+	// the internal token is cached with the auth result, instead of minting one for every request
+	firstInternal := me["internal_token"]
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/me", "upstream", &me))
+	assert.Equal(t, firstInternal, me["internal_token"])
+
+	assert.NoError(t, opts.InvalidateCachedToken(context.Background(), SchemeBearer, "upstream"))
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/me", "upstream", &me))
+	assert.NotEqual(t, firstInternal, me["internal_token"])
+
+	var exchanged ExchangeResponse
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/auth/exchange", "upstream", &exchanged))
+	assert.Equal(t, "Bearer", exchanged.TokenType)
+	assert.Greater(t, exchanged.ExpiresIn, 0)
+
+	// the upstream token was cached by the first request, and internal tokens never reach the provider
+	callsBefore := atomic.LoadInt32(&provider.calls)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/me", exchanged.AccessToken, &me))
+	assert.Equal(t, "upstream", me["sub"])
+	assert.Equal(t, exchanged.AccessToken, me["internal_token"])
+	assert.Equal(t, callsBefore, atomic.LoadInt32(&provider.calls))
+
+	t.Run("audience", func(t *testing.T) {
+		foreign, _, err := rt.CreateInternalToken(&UserInfo{Sub: "other-service-user"}, &CreateJWTOpts{
+			Issuer:    "fct-internal",
+			Audience:  "other-services",
+			ExpiresIn: time.Minute,
+		})
+		assert.NoError(t, err)
+
+		_, err = rt.ValidateInternalToken(foreign, "fct-internal", opts.Exchange.validateOpts(context.Background()))
+		assert.Error(t, err)
+
+		// the token isn't accepted as an internal token, so it is sent to the provider as an upstream token
+		callsBefore := atomic.LoadInt32(&provider.calls)
+
+		assert.Equal(t, http.StatusOK, do(http.MethodGet, "/me", foreign, &me))
+		assert.NotEqual(t, foreign, me["internal_token"])
+		assert.Equal(t, callsBefore+1, atomic.LoadInt32(&provider.calls))
+	})
+
+	t.Run("forwarding", func(t *testing.T) {
+		var forwarded string
+
+		downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			forwarded = r.Header.Get(fiber.HeaderAuthorization)
+		}))
+		defer downstream.Close()
+
+		ctx := context.WithValue(context.Background(), InternalTokenContextKey, "internal")
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, downstream.URL, nil)
+
+		client := &http.Client{Transport: &ForwardingTransport{}}
+
+		res, err := client.Do(req)
+		assert.NoError(t, err)
+		_ = res.Body.Close()
+
+		assert.Equal(t, "Bearer internal", forwarded)
+		assert.Empty(t, req.Header.Get(fiber.HeaderAuthorization))
+	})
+}
@@ -0,0 +1,115 @@
+package retokenizer
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+const (
+	// SchemeBearer is the scheme of tokens issued by an identity provider, wherever they are sent
+	SchemeBearer = "bearer"
+
+	// SchemeAPIKey is the scheme of API keys, see APIKeyProvider
+	SchemeAPIKey = "api-key"
+
+	DefaultAPIKeyHeader = "X-API-Key"
+)
+
+// Credential is a token found in a request, and the scheme used to authenticate it
+type Credential struct {
+	Scheme string
+	Token  string
+}
+
+// Extractor finds a credential in a request
+// it returns nil if the request has no credential for it, and an error if the credential can't be used,
+// which the middleware rejects as a malformed token
+type Extractor func(c *fiber.Ctx) (*Credential, error)
+
+// FromAuthorizationHeader extracts bearer tokens from the Authorization header, this is the default extractor
+func FromAuthorizationHeader() Extractor {
+	return func(c *fiber.Ctx) (*Credential, error) {
+		token, err := bearerToken(c)
+		if err == errMissingToken {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		return &Credential{Scheme: SchemeBearer, Token: token}, nil
+	}
+}
+
+// FromCookie extracts bearer tokens from a cookie, ie. one set HttpOnly by a browser app's login
+func FromCookie(name string) Extractor {
+	return func(c *fiber.Ctx) (*Credential, error) {
+		return credentialFrom(SchemeBearer, c.Cookies(name)), nil
+	}
+}
+
+// FromQuery extracts bearer tokens from a query param, for clients that can't set headers such as websocket upgrades
+// query params tend to end up in access logs, so tokens sent this way should be short-lived
+func FromQuery(param string) Extractor {
+	return func(c *fiber.Ctx) (*Credential, error) {
+		return credentialFrom(SchemeBearer, c.Query(param)), nil
+	}
+}
+
+// FromAPIKeyHeader extracts API keys from a header, defaults to DefaultAPIKeyHeader
+func FromAPIKeyHeader(header string) Extractor {
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+
+	return func(c *fiber.Ctx) (*Credential, error) {
+		return credentialFrom(SchemeAPIKey, c.Get(header)), nil
+	}
+}
+
+// FromHeader extracts credentials of any scheme from a header, with an optional prefix such as "Token "
+func FromHeader(header, prefix, scheme string) Extractor {
+	return func(c *fiber.Ctx) (*Credential, error) {
+		value := c.Get(header)
+		if value == "" {
+			return nil, nil
+		}
+
+		if !strings.HasPrefix(value, prefix) {
+			return nil, errMalformedToken
+		}
+
+		credential := credentialFrom(scheme, strings.TrimPrefix(value, prefix))
+		if credential == nil {
+			return nil, errMalformedToken
+		}
+
+		return credential, nil
+	}
+}
+
+func credentialFrom(scheme, token string) *Credential {
+	token = strings.TrimSpace(token)
+
+	if token == "" || token == "null" {
+		return nil
+	}
+
+	return &Credential{Scheme: scheme, Token: token}
+}
+
+// extractCredential runs the extractors in order, returning the first credential found
+// errMissingToken is returned if none of them found one
+func extractCredential(c *fiber.Ctx, extractors []Extractor) (*Credential, error) {
+	for _, extractor := range extractors {
+		credential, err := extractor(c)
+		if err != nil {
+			return nil, err
+		}
+
+		if credential != nil {
+			return credential, nil
+		}
+	}
+
+	return nil, errMissingToken
+}
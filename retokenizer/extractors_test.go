@@ -0,0 +1,104 @@
+package retokenizer
+
+import (
+	"context"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestExtractorsAndAPIKeys(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	rt := New(&Opts{})
+
+	keys := NewCacheAPIKeyStore(cache.NewInMemoryCache())
+	apiKeys := NewAPIKeyProvider(keys, []byte("api-key-secret"))
+
+	key, apiKey := apiKeys.GenerateAPIKey(&UserInfo{Sub: "service"}, nil)
+	assert.NotContains(t, apiKey.Hash, key)
+	assert.NoError(t, keys.StoreAPIKey(ctx, apiKey))
+
+	expired := time.Now().Add(-time.Minute)
+	expiredKey, expiredAPIKey := apiKeys.GenerateAPIKey(&UserInfo{Sub: "expired"}, &expired)
+	assert.NoError(t, keys.StoreAPIKey(ctx, expiredAPIKey))
+
+	opts := &MiddlewareOptions{
+		AuthProvider: &rejectingProvider{},
+		Providers:    map[string]AuthenticationProvider{SchemeAPIKey: apiKeys},
+		Extractors: []Extractor{
+			FromAuthorizationHeader(),
+			FromCookie("session_token"),
+			FromQuery("access_token"),
+			FromAPIKeyHeader(""),
+		},
+	}
+
+	app := fiber.New()
+	app.Use(ferr.Middleware(false))
+	app.Get("/", rt.MakeFiberMiddleware(opts), func(c *fiber.Ctx) error {
+		return c.SendString(ExtractUserInfo(c.UserContext()).Sub)
+	})
+
+	do := func(target string, headers map[string]string) (int, string) {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, _ := io.ReadAll(res.Body)
+
+		return res.StatusCode, string(body)
+	}
+
+	status, sub := do("/", map[string]string{fiber.HeaderCookie: "session_token=valid"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "1", sub)
+
+	status, _ = do("/?access_token=valid", nil)
+	assert.Equal(t, http.StatusOK, status)
+
+	status, sub = do("/", map[string]string{DefaultAPIKeyHeader: key})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "service", sub)
+
+	// api keys are only accepted by the api key provider, and bearer tokens only by the auth provider
+	status, _ = do("/", map[string]string{fiber.HeaderAuthorization: "Bearer " + key})
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = do("/", map[string]string{DefaultAPIKeyHeader: "valid"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = do("/", map[string]string{DefaultAPIKeyHeader: expiredKey})
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	// extractors run in order, so the header wins over the query param
+	status, _ = do("/?access_token=valid", map[string]string{fiber.HeaderAuthorization: "Bearer invalid"})
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	status, _ = do("/", nil)
+	assert.Equal(t, http.StatusUnauthorized, status)
+
+	t.Run("deleted keys are rejected right away", func(t *testing.T) {
+		// the user of the key is cached by the earlier request
+		_, err := opts.Cache.RetrieveValue(ctx, opts.authCacheKey(&Credential{Scheme: SchemeAPIKey, Token: key}))
+		assert.NoError(t, err)
+
+		assert.NoError(t, keys.DeleteAPIKey(ctx, apiKey.Hash, opts))
+
+		status, _ := do("/", map[string]string{DefaultAPIKeyHeader: key})
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+}
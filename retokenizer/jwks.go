@@ -0,0 +1,318 @@
+package retokenizer
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	DefaultJWKSCacheDuration      = time.Hour
+	DefaultJWKSMinRefreshInterval = time.Minute
+)
+
+// JSONWebKey is a single public key of a JSONWebKeySet (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA keys
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP keys, OKP keys only use X
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// NewJSONWebKey encodes a public key, RSA, EC (P-256, P-384, P-521) and Ed25519 keys are supported
+func NewJSONWebKey(kid string, alg string, public crypto.PublicKey) (JSONWebKey, error) {
+	jwk := JSONWebKey{Kid: kid, Use: "sig", Alg: alg}
+
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	case *ecdsa.PublicKey:
+		// coordinates are padded to the size of the curve, as RFC 7518 requires
+		size := (key.Curve.Params().BitSize + 7) / 8
+
+		jwk.Kty = "EC"
+		jwk.Crv = key.Curve.Params().Name
+		jwk.X = base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, size)))
+		jwk.Y = base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(key)
+	default:
+		return jwk, fmt.Errorf("unsupported public key type %T", public)
+	}
+
+	return jwk, nil
+}
+
+// Thumbprint computes the RFC 7638 thumbprint of the key, which is a stable id for it
+func (k *JSONWebKey) Thumbprint() (string, error) {
+	// the required members of each key type, in lexicographic order
+	var members []string
+
+	switch k.Kty {
+	case "RSA":
+		members = []string{"e", k.E, "kty", k.Kty, "n", k.N}
+	case "EC":
+		members = []string{"crv", k.Crv, "kty", k.Kty, "x", k.X, "y", k.Y}
+	case "OKP":
+		members = []string{"crv", k.Crv, "kty", k.Kty, "x", k.X}
+	default:
+		return "", fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	for i := 0; i < len(members); i += 2 {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		fmt.Fprintf(&buf, "%q:%q", members[i], members[i+1])
+	}
+
+	buf.WriteByte('}')
+
+	sum := sha256.Sum256(buf.Bytes())
+
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// PublicKey decodes the key, RSA, EC (P-256, P-384, P-521) and Ed25519 keys are supported
+func (k *JSONWebKey) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, ferr.Wrap(err)
+		}
+
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 public key size")
+		}
+
+		return ed25519.PublicKey(x), nil
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
+
+// keySetError is returned when the key set could not be loaded, as opposed to the token using an unknown key
+type keySetError struct {
+	err error
+}
+
+func (e *keySetError) Error() string {
+	return fmt.Sprintf("failed to load key set: %v", e.err)
+}
+
+func (e *keySetError) Unwrap() error {
+	return e.err
+}
+
+// remoteKeySet fetches and caches the keys published at a JWKS url
+// keys are refetched when the cache expires, or when a token uses a key id that isn't known yet (key rotation)
+// unknown key ids refetch at most once per minRefreshInterval, so bad tokens can't be used to hammer the issuer
+type remoteKeySet struct {
+	url                string
+	client             *http.Client
+	cacheDuration      time.Duration
+	minRefreshInterval time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+func (r *remoteKeySet) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	r.mu.RLock()
+	key, ok := r.lookup(kid)
+	fresh := time.Since(r.fetchedAt) < r.cacheDuration
+	r.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// another request may have refreshed the keys while this one was waiting for the lock
+	if time.Since(r.fetchedAt) >= r.minRefreshInterval || r.keys == nil {
+		if err := r.refresh(ctx); err != nil {
+			// keep using a cached key if the issuer is temporarily unavailable
+			if ok {
+				return key, nil
+			}
+
+			return nil, &keySetError{err: err}
+		}
+	}
+
+	key, ok = r.lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	return key, nil
+}
+
+// lookup finds a key by id, tokens without a key id can only be verified when the set has a single key
+func (r *remoteKeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(r.keys) == 1 {
+		for _, key := range r.keys {
+			return key, true
+		}
+	}
+
+	key, ok := r.keys[kid]
+
+	return key, ok
+}
+
+func (r *remoteKeySet) refresh(ctx context.Context) error {
+	var set JSONWebKeySet
+
+	if err := getJSON(ctx, r.client, r.url, "", &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for i := range set.Keys {
+		jwk := &set.Keys[i]
+
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.PublicKey()
+		if err != nil {
+			// one unsupported key shouldn't prevent using the others
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	r.keys = keys
+	r.fetchedAt = time.Now()
+
+	return nil
+}
+
+// getJSON makes a GET request and decodes the json response, bearerToken is sent as the Authorization header if set
+// 401 and 403 responses return ErrInvalidAuthorization
+func getJSON(ctx context.Context, client *http.Client, url string, bearerToken string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if bearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+bearerToken)
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return ErrInvalidAuthorization
+	case res.StatusCode != http.StatusOK:
+		return fmt.Errorf("unexpected status %d from %s", res.StatusCode, url)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
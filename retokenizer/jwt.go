@@ -1,6 +1,9 @@
 package retokenizer
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
 	"github.com/golang-jwt/jwt/v4"
@@ -10,6 +13,16 @@ import (
 type JWTClaims[U any] struct {
 	jwt.RegisteredClaims
 	User *U `json:"user"`
+
+	// Act is the end user a service token was minted on behalf of, see ServiceTokenSource
+	Act *Actor `json:"act,omitempty"`
+}
+
+// Actor identifies who a token acts for, following the act claim of RFC 8693
+// actors can be nested when a call passes through several services
+type Actor struct {
+	Sub string `json:"sub"`
+	Act *Actor `json:"act,omitempty"`
 }
 
 type CreateJWTOpts struct {
@@ -18,34 +31,78 @@ type CreateJWTOpts struct {
 	ExpiresIn time.Duration
 }
 
+type ValidateJWTOpts struct {
+	// Audience requires the aud claim of tokens to contain it, tokens aren't checked for an audience when it is empty
+	Audience string
+
+	// Revocations rejects tokens that were revoked, either by their id or by revoking every token of their subject
+	Revocations *RevocationList
+
+	// Context is used to check Revocations, defaults to context.Background()
+	Context context.Context
+}
+
+var ErrTokenRevoked = errors.New("token has been revoked")
+
 func CreateJWTForUser[U any](key []byte, opts *CreateJWTOpts, sub string, user *U) (string, *JWTClaims[U], error) {
-	claims := JWTClaims[U]{
+	claims := newUserClaims(opts, sub, user)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+	ss, err := token.SignedString(key)
+	if err != nil {
+		return "", nil, ferr.Wrap(err)
+	}
+
+	return ss, claims, nil
+}
+
+// CreateJWTForUserWithKeySet is CreateJWTForUser, signing with the active key of ks
+func CreateJWTForUserWithKeySet[U any](ks *KeySet, opts *CreateJWTOpts, sub string, user *U) (string, *JWTClaims[U], error) {
+	claims := newUserClaims(opts, sub, user)
+
+	ss, err := ks.Sign(claims)
+	if err != nil {
+		return "", nil, ferr.Wrap(err)
+	}
+
+	return ss, claims, nil
+}
+
+func newUserClaims[U any](opts *CreateJWTOpts, sub string, user *U) *JWTClaims[U] {
+	return &JWTClaims[U]{
 		RegisteredClaims: jwt.RegisteredClaims{
 			Audience:  jwt.ClaimStrings{opts.Audience},
 			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(opts.ExpiresIn)),
 			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
 			Issuer:    opts.Issuer,
 			Subject:   sub,
+			ID:        newTokenID(),
 		},
 		User: user,
 	}
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	ss, err := token.SignedString(key)
+func ValidateUserJWT[U any](key []byte, token, issuer string, opts ...*ValidateJWTOpts) (*U, error) {
+	parsedToken, err := jwt.ParseWithClaims(token, &JWTClaims[U]{}, KeyBasedKeyFunc(key), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
 	if err != nil {
-		return "", nil, ferr.Wrap(err)
+		return nil, ferr.Wrap(err)
 	}
 
-	return ss, &claims, nil
+	return userFromToken[U](parsedToken, issuer, opts)
 }
 
-func ValidateUserJWT[U any](key []byte, token, issuer string) (*U, error) {
-	parsedToken, err := jwt.ParseWithClaims(token, &JWTClaims[U]{}, KeyBasedKeyFunc(key), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+// ValidateUserJWTWithKeySet is ValidateUserJWT, verifying the token with the key in ks matching its kid header
+func ValidateUserJWTWithKeySet[U any](ks *KeySet, token, issuer string, opts ...*ValidateJWTOpts) (*U, error) {
+	parsedToken, err := jwt.ParseWithClaims(token, &JWTClaims[U]{}, ks.KeyFunc(), jwt.WithValidMethods(ks.ValidMethods()))
 	if err != nil {
 		return nil, ferr.Wrap(err)
 	}
 
+	return userFromToken[U](parsedToken, issuer, opts)
+}
+
+func userFromToken[U any](parsedToken *jwt.Token, issuer string, opts []*ValidateJWTOpts) (*U, error) {
 	if !parsedToken.Valid {
 		return nil, errors.New("invalid token")
 	}
@@ -57,8 +114,45 @@ func ValidateUserJWT[U any](key []byte, token, issuer string) (*U, error) {
 			return nil, errors.New("token expired")
 		}
 
+		if len(opts) > 0 && opts[0] != nil && opts[0].Audience != "" && !claims.VerifyAudience(opts[0].Audience, true) {
+			return nil, errors.New("invalid audience")
+		}
+
+		if len(opts) > 0 && opts[0] != nil && opts[0].Revocations != nil {
+			ctx := opts[0].Context
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			revoked, err := opts[0].Revocations.IsRevoked(ctx, &claims.RegisteredClaims)
+			if err != nil {
+				return nil, ferr.Wrap(err)
+			}
+
+			if revoked {
+				return nil, ErrTokenRevoked
+			}
+		}
+
 		return claims.User, nil
 	}
 
 	return nil, errors.New("invalid token")
 }
+
+// newTokenID generates a random jti, so individual tokens can be revoked
+func newTokenID() string {
+	return randomToken(16)
+}
+
+// randomToken generates n random bytes, encoded as url safe base64
+func randomToken(n int) string {
+	b := make([]byte, n)
+
+	// crypto/rand only fails if the OS can't provide randomness, which nothing can recover from
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,303 @@
+package retokenizer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultJWKSMaxAge is how long clients may cache the response of KeySet.JWKSHandler
+var DefaultJWKSMaxAge = 5 * time.Minute
+
+// SigningKey is a key a KeySet can sign tokens with
+type SigningKey struct {
+	// ID is sent as the kid header of tokens signed with this key
+	ID string
+
+	Method jwt.SigningMethod
+
+	// PrivateKey is a []byte for HMAC methods, or a crypto.Signer (*rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey)
+	PrivateKey any
+
+	// RetiredAt is when the key stopped being used for signing, it is set by the KeySet when another key is activated
+	RetiredAt *time.Time
+}
+
+// NewSigningKey wraps an existing key, an empty id is replaced with the RFC 7638 thumbprint of asymmetric keys
+func NewSigningKey(id string, method jwt.SigningMethod, privateKey any) (*SigningKey, error) {
+	key := &SigningKey{ID: id, Method: method, PrivateKey: privateKey}
+
+	if id == "" {
+		jwk, err := key.JSONWebKey()
+		if err != nil {
+			return nil, ferr.Wrapf(err, "key id is required for symmetric keys")
+		}
+
+		key.ID, err = jwk.Thumbprint()
+		if err != nil {
+			return nil, ferr.Wrap(err)
+		}
+	}
+
+	return key, nil
+}
+
+// GenerateRSASigningKey generates an RS256 key, bits should be at least 2048
+func GenerateRSASigningKey(bits int) (*SigningKey, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return NewSigningKey("", jwt.SigningMethodRS256, privateKey)
+}
+
+// GenerateEd25519SigningKey generates an EdDSA key
+func GenerateEd25519SigningKey() (*SigningKey, error) {
+	_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return NewSigningKey("", jwt.SigningMethodEdDSA, privateKey)
+}
+
+// GenerateES256SigningKey generates an ES256 key
+func GenerateES256SigningKey() (*SigningKey, error) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return NewSigningKey("", jwt.SigningMethodES256, privateKey)
+}
+
+// IsSymmetric is true for HMAC keys, which are never published
+func (k *SigningKey) IsSymmetric() bool {
+	_, ok := k.PrivateKey.([]byte)
+	return ok
+}
+
+// VerificationKey returns the key used to verify tokens, the public key for asymmetric keys
+func (k *SigningKey) VerificationKey() any {
+	if signer, ok := k.PrivateKey.(crypto.Signer); ok {
+		return signer.Public()
+	}
+
+	return k.PrivateKey
+}
+
+// JSONWebKey returns the public key in JWK format, symmetric keys return an error
+func (k *SigningKey) JSONWebKey() (JSONWebKey, error) {
+	if k.IsSymmetric() {
+		return JSONWebKey{}, errors.New("symmetric keys can't be published")
+	}
+
+	return NewJSONWebKey(k.ID, k.Method.Alg(), k.VerificationKey())
+}
+
+// KeySet holds the keys used to sign and verify tokens
+// one key is active and signs new tokens, other keys only verify tokens:
+// keys added with AddKey are published ahead of being activated so that verifiers can cache them,
+// and retired keys keep verifying tokens for the overlap window after they stop signing.
+// The overlap should be at least as long as the longest lived token. It is safe for concurrent use
+type KeySet struct {
+	mu      sync.RWMutex
+	active  *SigningKey
+	keys    map[string]*SigningKey
+	overlap time.Duration
+	now     func() time.Time
+}
+
+func NewKeySet(active *SigningKey, overlap time.Duration) (*KeySet, error) {
+	ks := &KeySet{
+		keys:    map[string]*SigningKey{},
+		overlap: overlap,
+		now:     time.Now,
+	}
+
+	if err := ks.Rotate(active); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// AddKey adds a key that verifies tokens and is published, but doesn't sign until it is activated
+func (ks *KeySet) AddKey(key *SigningKey) error {
+	if key == nil || key.ID == "" || key.Method == nil || key.PrivateKey == nil {
+		return ferr.MissingArgument("key")
+	}
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	if _, exists := ks.keys[key.ID]; exists {
+		return fmt.Errorf("key %q is already in the key set", key.ID)
+	}
+
+	ks.keys[key.ID] = key
+
+	return nil
+}
+
+// Activate makes a key in the set the signing key, the previously active key is retired
+func (ks *KeySet) Activate(kid string) error {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	key, ok := ks.keys[kid]
+	if !ok {
+		return fmt.Errorf("key %q is not in the key set", kid)
+	}
+
+	if ks.active != nil && ks.active != key {
+		now := ks.now()
+		ks.active.RetiredAt = &now
+	}
+
+	key.RetiredAt = nil
+	ks.active = key
+
+	return nil
+}
+
+// Rotate adds a key and immediately activates it
+func (ks *KeySet) Rotate(next *SigningKey) error {
+	if err := ks.AddKey(next); err != nil {
+		return err
+	}
+
+	return ks.Activate(next.ID)
+}
+
+// Prune removes retired keys whose overlap window has passed
+func (ks *KeySet) Prune() {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	for kid, key := range ks.keys {
+		if ks.expired(key) {
+			delete(ks.keys, kid)
+		}
+	}
+}
+
+// Active returns the key new tokens are signed with
+func (ks *KeySet) Active() *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	return ks.active
+}
+
+// Sign signs claims with the active key, and sets the kid header
+func (ks *KeySet) Sign(claims jwt.Claims) (string, error) {
+	key := ks.Active()
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	signed, err := token.SignedString(key.PrivateKey)
+	if err != nil {
+		return "", ferr.Wrap(err)
+	}
+
+	return signed, nil
+}
+
+// KeyFunc finds the verification key for a token by its kid header
+// the token's alg must match the key's method, so a public key can never be used as an HMAC secret
+func (ks *KeySet) KeyFunc() jwt.Keyfunc {
+	return func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token has no key id")
+		}
+
+		ks.mu.RLock()
+		defer ks.mu.RUnlock()
+
+		key, ok := ks.keys[kid]
+		if !ok || ks.expired(key) {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("key %q can't verify %s tokens", kid, token.Method.Alg())
+		}
+
+		return key.VerificationKey(), nil
+	}
+}
+
+// ValidMethods lists the algorithms of the keys in the set, for use with jwt.WithValidMethods
+func (ks *KeySet) ValidMethods() []string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	methods := map[string]bool{}
+	for _, key := range ks.keys {
+		methods[key.Method.Alg()] = true
+	}
+
+	var algs []string
+	for alg := range methods {
+		algs = append(algs, alg)
+	}
+
+	sort.Strings(algs)
+
+	return algs
+}
+
+// PublicJWKS returns the public keys that can currently verify tokens, symmetric keys are left out
+func (ks *KeySet) PublicJWKS() JSONWebKeySet {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	for _, key := range ks.keys {
+		if key.IsSymmetric() || ks.expired(key) {
+			continue
+		}
+
+		jwk, err := key.JSONWebKey()
+		if err != nil {
+			continue
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	sort.Slice(set.Keys, func(i, j int) bool {
+		return set.Keys[i].Kid < set.Keys[j].Kid
+	})
+
+	return set
+}
+
+// JWKSHandler serves the public keys of the set, usually mounted at /.well-known/jwks.json
+func (ks *KeySet) JWKSHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(DefaultJWKSMaxAge.Seconds())))
+		return c.JSON(ks.PublicJWKS())
+	}
+}
+
+// expired is true once a retired key's overlap window has passed, callers must hold the lock
+func (ks *KeySet) expired(key *SigningKey) bool {
+	return key.RetiredAt != nil && ks.now().After(key.RetiredAt.Add(ks.overlap))
+}
@@ -0,0 +1,98 @@
+package retokenizer
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type keySetTestUser struct {
+	Name string `json:"name"`
+}
+
+func TestKeySet(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := GenerateRSASigningKey(2048)
+	assert.NoError(t, err)
+
+	edKey, err := GenerateEd25519SigningKey()
+	assert.NoError(t, err)
+
+	ks, err := NewKeySet(rsaKey, time.Hour)
+	assert.NoError(t, err)
+
+	opts := &CreateJWTOpts{Audience: "api", Issuer: "fct", ExpiresIn: time.Minute}
+
+	rsaToken, _, err := CreateJWTForUserWithKeySet(ks, opts, "1", &keySetTestUser{Name: "alice"})
+	assert.NoError(t, err)
+
+	user, err := ValidateUserJWTWithKeySet[keySetTestUser](ks, rsaToken, "fct")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", user.Name)
+
+	t.Run("rotation keeps retired keys for the overlap window", func(t *testing.T) {
+		assert.NoError(t, ks.Rotate(edKey))
+		assert.Equal(t, edKey.ID, ks.Active().ID)
+
+		edToken, _, err := CreateJWTForUserWithKeySet(ks, opts, "2", &keySetTestUser{Name: "bob"})
+		assert.NoError(t, err)
+
+		parsed, _, _ := jwt.NewParser().ParseUnverified(edToken, jwt.MapClaims{})
+		assert.Equal(t, "EdDSA", parsed.Method.Alg())
+
+		_, err = ValidateUserJWTWithKeySet[keySetTestUser](ks, edToken, "fct")
+		assert.NoError(t, err)
+
+		_, err = ValidateUserJWTWithKeySet[keySetTestUser](ks, rsaToken, "fct")
+		assert.NoError(t, err)
+		assert.Len(t, ks.PublicJWKS().Keys, 2)
+
+		ks.mu.Lock()
+		ks.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+		ks.mu.Unlock()
+
+		_, err = ValidateUserJWTWithKeySet[keySetTestUser](ks, rsaToken, "fct")
+		assert.Error(t, err)
+		assert.Len(t, ks.PublicJWKS().Keys, 1)
+
+		ks.Prune()
+		assert.NotContains(t, ks.keys, rsaKey.ID)
+	})
+
+	t.Run("alg must match the key", func(t *testing.T) {
+		// an HS256 token using the published public key as the secret must not verify
+		jwk, _ := edKey.JSONWebKey()
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": "fct"})
+		token.Header["kid"] = edKey.ID
+		forged, _ := token.SignedString([]byte(jwk.X))
+
+		_, err := ValidateUserJWTWithKeySet[keySetTestUser](ks, forged, "fct")
+		assert.Error(t, err)
+	})
+
+	t.Run("jwks handler", func(t *testing.T) {
+		app := fiber.New()
+		app.Get("/.well-known/jwks.json", ks.JWKSHandler())
+
+		res, err := app.Test(httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		assert.NoError(t, err)
+		assert.Equal(t, "public, max-age=300", res.Header.Get(fiber.HeaderCacheControl))
+
+		var set JSONWebKeySet
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&set))
+
+		if assert.Len(t, set.Keys, 1) {
+			assert.Equal(t, edKey.ID, set.Keys[0].Kid)
+
+			public, err := set.Keys[0].PublicKey()
+			assert.NoError(t, err)
+			assert.Equal(t, edKey.VerificationKey(), public)
+		}
+	})
+}
@@ -2,18 +2,19 @@ package retokenizer
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
 	lggr "github.com/datomar-labs-inc/FCT_Helpers_Go/logger"
 	"github.com/gofiber/fiber/v2"
 	"go.uber.org/zap"
-	"net/http"
 	"strings"
 	"time"
 )
 
 type ContextKey string
 
+// ErrMissingTokenMsg and the other fiber.Map bodies are responded with when MiddlewareOptions.LegacyErrorBodies is set
 var ErrMissingTokenMsg = fiber.Map{
 	"error": "missing token",
 }
@@ -40,30 +41,33 @@ type MiddlewareOptions struct {
 	AuthProvider        AuthenticationProvider
 	AuthCacheDuration   *time.Duration
 	Logger              *lggr.LogWrapper
-}
 
-//revive:disable:cyclomatic Honestly this function is super complicated, but not worth rewriting
-// TODO this is tech debt
-func (rt *ReTokenizer) MakeFiberMiddleware(opts *MiddlewareOptions) func(c *fiber.Ctx) error {
-	if opts.Cache == nil {
-		opts.Cache = cache.NewInMemoryCache()
-	}
+	// Exchange enables exchange mode, see ExchangeOptions
+	Exchange *ExchangeOptions
 
-	if opts.AuthProvider == nil {
-		panic("missing auth provider")
-	}
+	// Realm is included in WWW-Authenticate challenges when set
+	Realm string
 
-	if opts.AuthCacheDuration == nil {
-		opts.AuthCacheDuration = &DefaultAuthCacheDuration
-	}
+	// LegacyErrorBodies responds with ErrMissingTokenMsg and the other fiber.Map bodies, instead of returning ferr errors
+	LegacyErrorBodies bool
 
-	var logger *lggr.LogWrapper
+	// Extractors are tried in order until one finds a credential, defaults to FromAuthorizationHeader
+	Extractors []Extractor
 
-	if opts.Logger != nil {
-		logger = opts.Logger
-	} else {
-		logger = lggr.GetDetached("retokenizer-middleware")
-	}
+	// Providers authenticate credentials of each scheme, AuthProvider is used for schemes without one
+	// ie. {SchemeAPIKey: NewAPIKeyProvider(store, secret)}
+	Providers map[string]AuthenticationProvider
+
+	// CacheKeySecret is the HMAC key tokens are hashed with to build cache keys, so the cache never holds raw tokens
+	// defaults to a random secret, which must be replaced by a shared one for instances sharing a cache
+	CacheKeySecret []byte
+}
+
+// MakeFiberMiddleware authenticates requests, attaching the user for ExtractUserInfo
+// rejected requests return ferr errors (ferr.MissingToken, ferr.MalformedToken, ferr.TokenExpired or ferr.InvalidToken)
+// meant to be rendered by ferr.Middleware, see MiddlewareOptions.LegacyErrorBodies for the previous bodies
+func (rt *ReTokenizer) MakeFiberMiddleware(opts *MiddlewareOptions) func(c *fiber.Ctx) error {
+	opts = rt.withMiddlewareDefaults(opts)
 
 	return func(c *fiber.Ctx) error {
 		spanCtx, span := rt.tracer.Start(c.UserContext(), "retokenizer")
@@ -74,67 +78,159 @@ func (rt *ReTokenizer) MakeFiberMiddleware(opts *MiddlewareOptions) func(c *fibe
 			}
 		}()
 
-		token := c.GetReqHeaders()["Authorization"]
-
-		if token == "" || token == "null" {
-			if opts.IgnoreMissingTokens {
+		credential, err := extractCredential(c, opts.Extractors)
+		if err != nil {
+			if (err == errMissingToken && opts.IgnoreMissingTokens) || (err != errMissingToken && opts.IgnoreInvalidTokens) {
 				return c.Next()
 			}
 
-			return c.
-				Status(http.StatusUnauthorized).
-				JSON(ErrMissingTokenMsg)
+			return opts.respondError(c, tokenError(err))
 		}
 
-		if !strings.HasPrefix(token, "Bearer ") {
-			if opts.IgnoreInvalidTokens {
+		token := credential.Token
+		ctx := c.UserContext()
+
+		if opts.Exchange != nil && credential.Scheme == SchemeBearer {
+			// internal tokens minted by the exchange endpoint are verified locally, without calling the auth provider
+			userInfo, err := rt.ValidateInternalToken(token, opts.Exchange.Issuer, opts.Exchange.validateOpts(spanCtx))
+			if err == nil {
+				ctx = context.WithValue(ctx, InternalTokenContextKey, token)
+				c.SetUserContext(context.WithValue(ctx, UserInfoContextKey, userInfo))
+
 				return c.Next()
+			} else if errors.Is(err, ErrTokenRevoked) {
+				return opts.respondError(c, ferr.InvalidToken)
+			} else if isTokenExpired(err) {
+				return opts.respondError(c, ferr.TokenExpired)
 			}
+		}
 
-			return c.
-				Status(http.StatusUnauthorized).
-				JSON(ErrMalformedTokenMsg)
+		userInfo, err := rt.authenticate(spanCtx, opts, credential)
+		if err != nil {
+			return opts.respondError(c, err)
 		}
 
-		token = strings.TrimSpace(strings.TrimPrefix(token, "Bearer"))
+		if opts.Exchange != nil {
+			internalToken, err := rt.internalTokenFor(spanCtx, opts, credential, userInfo)
+			if err != nil {
+				opts.Logger.Error("failed to mint internal token", zap.Error(err))
 
-		if token == "" {
-			if opts.IgnoreInvalidTokens {
-				return c.Next()
+				return opts.respondError(c, internalError())
 			}
 
-			return c.
-				Status(http.StatusUnauthorized).
-				JSON(ErrMalformedTokenMsg)
+			ctx = context.WithValue(ctx, InternalTokenContextKey, internalToken)
 		}
 
-		userInfo, err := cache.GetCachedJSONValueWithExpiry(
-			spanCtx,
-			opts.Cache,
-			fmt.Sprintf("auth:%s", token),
-			func(ctx context.Context) (*UserInfo, error) {
-				return opts.AuthProvider.GetUserInfo(ctx, token)
-			},
-			opts.AuthCacheDuration,
-		)
-		if err != nil && err != ErrInvalidAuthorization {
-			logger.Error("failed to authorize user", zap.Error(err))
-
-			return c.
-				Status(http.StatusInternalServerError).
-				JSON(ErrInternalServerErrMsg)
-		} else if err == ErrInvalidAuthorization {
-			return c.
-				Status(http.StatusUnauthorized).
-				JSON(ErrInvalidAuthorizationMsg)
-		}
-
-		c.SetUserContext(context.WithValue(c.UserContext(), UserInfoContextKey, userInfo))
+		c.SetUserContext(context.WithValue(ctx, UserInfoContextKey, userInfo))
 
 		return c.Next()
 	}
 }
 
+func (rt *ReTokenizer) withMiddlewareDefaults(opts *MiddlewareOptions) *MiddlewareOptions {
+	if opts.Cache == nil {
+		opts.Cache = cache.NewInMemoryCache()
+	}
+
+	if opts.AuthProvider == nil && len(opts.Providers) == 0 {
+		panic("missing auth provider")
+	}
+
+	if len(opts.Extractors) == 0 {
+		opts.Extractors = []Extractor{FromAuthorizationHeader()}
+	}
+
+	if opts.AuthCacheDuration == nil {
+		opts.AuthCacheDuration = &DefaultAuthCacheDuration
+	}
+
+	if len(opts.CacheKeySecret) == 0 {
+		opts.CacheKeySecret = []byte(randomToken(32))
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = lggr.GetDetached("retokenizer-middleware")
+	}
+
+	if opts.Exchange != nil && rt.keySet == nil && len(rt.key) == 0 {
+		panic("exchange mode requires a JWT signing key or key set")
+	}
+
+	return opts
+}
+
+var (
+	errMissingToken   = errors.New("missing token")
+	errMalformedToken = errors.New("malformed token")
+)
+
+// bearerToken extracts the token from the Authorization header, returning errMissingToken or errMalformedToken if there isn't a usable one
+func bearerToken(c *fiber.Ctx) (string, error) {
+	token := c.GetReqHeaders()["Authorization"]
+
+	if token == "" || token == "null" {
+		return "", errMissingToken
+	}
+
+	if !strings.HasPrefix(token, "Bearer ") {
+		return "", errMalformedToken
+	}
+
+	token = strings.TrimSpace(strings.TrimPrefix(token, "Bearer"))
+
+	if token == "" {
+		return "", errMalformedToken
+	}
+
+	return token, nil
+}
+
+// providerFor returns the AuthenticationProvider for a scheme, or nil if no provider accepts it
+func (opts *MiddlewareOptions) providerFor(scheme string) AuthenticationProvider {
+	if provider, ok := opts.Providers[scheme]; ok {
+		return provider
+	}
+
+	return opts.AuthProvider
+}
+
+// authenticate resolves the user for an upstream credential through the cache and the provider of its scheme
+// credentials that are not accepted return ferr.TokenExpired or ferr.InvalidToken
+func (rt *ReTokenizer) authenticate(ctx context.Context, opts *MiddlewareOptions, credential *Credential) (*UserInfo, error) {
+	provider := opts.providerFor(credential.Scheme)
+	if provider == nil {
+		return nil, ferr.InvalidToken
+	}
+
+	getUserInfo := func(ctx context.Context) (*UserInfo, error) {
+		return provider.GetUserInfo(ctx, credential.Token)
+	}
+
+	var userInfo *UserInfo
+	var err error
+
+	// tokens expiring before the cache duration are only cached until they expire, and expired ones aren't cached at all
+	if ttl := opts.authCacheTTL(credential.Token); ttl > 0 {
+		userInfo, err = cache.GetCachedJSONValueWithExpiry(ctx, opts.Cache, opts.authCacheKey(credential), getUserInfo, &ttl)
+	} else {
+		userInfo, err = getUserInfo(ctx)
+	}
+
+	if err != nil && !errors.Is(err, ErrInvalidAuthorization) {
+		opts.Logger.Error("failed to authorize user", zap.Error(err))
+
+		return nil, internalError()
+	} else if errors.Is(err, ErrInvalidAuthorization) {
+		if isTokenExpired(err) {
+			return nil, ferr.TokenExpired
+		}
+
+		return nil, ferr.InvalidToken
+	}
+
+	return userInfo, nil
+}
+
 // ExtractUserInfo will extract user info attached during auth middleware
 // meant to be called with fiber's UserContext
 // ie. ExtractUserInfo(c.UserContext())
@@ -0,0 +1,105 @@
+package retokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// rejectingProvider only accepts the token "valid"
+type rejectingProvider struct{}
+
+func (p *rejectingProvider) GetUserInfo(_ context.Context, token string) (*UserInfo, error) {
+	if token != "valid" {
+		return nil, ErrInvalidAuthorization
+	}
+
+	return &UserInfo{Sub: "1"}, nil
+}
+
+func TestMiddlewareErrors(t *testing.T) {
+	t.Parallel()
+
+	rt := New(&Opts{JWTSigningKey: []byte("internal-signing-key")})
+
+	expired, _, err := rt.CreateInternalToken(&UserInfo{Sub: "1"}, &CreateJWTOpts{Issuer: "fct-internal", ExpiresIn: -time.Minute})
+	assert.NoError(t, err)
+
+	newApp := func(legacy bool) *fiber.App {
+		opts := &MiddlewareOptions{
+			AuthProvider:      &rejectingProvider{},
+			Exchange:          &ExchangeOptions{Issuer: "fct-internal"},
+			Realm:             "fct",
+			LegacyErrorBodies: legacy,
+		}
+
+		app := fiber.New()
+		app.Use(ferr.Middleware(false))
+		app.Get("/", rt.MakeFiberMiddleware(opts), func(c *fiber.Ctx) error {
+			return c.SendStatus(http.StatusOK)
+		})
+
+		return app
+	}
+
+	do := func(app *fiber.App, authorization string) (*http.Response, map[string]any) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if authorization != "" {
+			req.Header.Set(fiber.HeaderAuthorization, authorization)
+		}
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body map[string]any
+		_ = json.NewDecoder(res.Body).Decode(&body)
+
+		return res, body
+	}
+
+	app := newApp(false)
+
+	tests := []struct {
+		authorization string
+		status        int
+		code          string
+		challenge     string
+	}{
+		{"", http.StatusUnauthorized, ferr.CodeMissingToken, `Bearer realm="fct"`},
+		{"Basic abc", http.StatusBadRequest, ferr.CodeMalformedToken, `Bearer realm="fct", error="invalid_request", error_description="the authentication token is malformed"`},
+		{"Bearer " + expired, http.StatusUnauthorized, ferr.CodeTokenExpired, `Bearer realm="fct", error="invalid_token", error_description="the authentication token has expired"`},
+		{"Bearer invalid", http.StatusUnauthorized, ferr.CodeInvalidToken, `Bearer realm="fct", error="invalid_token", error_description="the authentication token is invalid"`},
+	}
+
+	for _, test := range tests {
+		res, body := do(app, test.authorization)
+
+		assert.Equal(t, test.status, res.StatusCode, test.code)
+		assert.Equal(t, test.code, body["code"])
+		assert.Equal(t, test.challenge, res.Header.Get(fiber.HeaderWWWAuthenticate))
+	}
+
+	res, _ := do(app, "Bearer valid")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	t.Run("legacy bodies", func(t *testing.T) {
+		app := newApp(true)
+
+		res, body := do(app, "Basic abc")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "malformed token", body["error"])
+		assert.NotEmpty(t, res.Header.Get(fiber.HeaderWWWAuthenticate))
+
+		res, body = do(app, "Bearer invalid")
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Equal(t, "invalid authorization", body["error"])
+	})
+}
@@ -0,0 +1,444 @@
+package retokenizer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// DefaultRefreshTokenLifetime is how long a refresh token family stays valid without being used
+var DefaultRefreshTokenLifetime = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrRefreshTokenReused is returned when a refresh token that was already rotated is used again
+	// the whole family is revoked when this happens, since either the client or an attacker holds a stolen token
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+var ErrInvalidRefreshTokenMsg = fiber.Map{
+	"error": "invalid refresh token",
+}
+
+// RefreshTokenStore issues and rotates opaque refresh tokens, stored in a cache.Cache
+// every token belongs to a family, which starts when a user logs in. Refreshing marks the token as used and issues
+// the next token of the family, and presenting a used token again revokes the whole family
+//
+// the cache is not expected to provide atomic operations, so two concurrent refreshes of the same token may both succeed
+type RefreshTokenStore struct {
+	cache    cache.Cache
+	lifetime time.Duration
+}
+
+type refreshTokenRecord struct {
+	FamilyID  string    `json:"family_id"`
+	User      *UserInfo `json:"user"`
+	Used      bool      `json:"used"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type refreshTokenFamily struct {
+	Sub string `json:"sub"`
+}
+
+// NewRefreshTokenStore creates a RefreshTokenStore, lifetime defaults to DefaultRefreshTokenLifetime
+func NewRefreshTokenStore(c cache.Cache, lifetime time.Duration) *RefreshTokenStore {
+	if lifetime <= 0 {
+		lifetime = DefaultRefreshTokenLifetime
+	}
+
+	return &RefreshTokenStore{
+		cache:    c,
+		lifetime: lifetime,
+	}
+}
+
+// Lifetime is how long tokens issued by the store are valid
+func (s *RefreshTokenStore) Lifetime() time.Duration {
+	return s.lifetime
+}
+
+// Issue starts a new token family for a user, and returns its first refresh token
+func (s *RefreshTokenStore) Issue(ctx context.Context, user *UserInfo) (string, error) {
+	familyID := randomToken(16)
+
+	err := s.storeJSON(ctx, refreshFamilyKey(familyID), &refreshTokenFamily{Sub: user.Sub}, s.lifetime)
+	if err != nil {
+		return "", ferr.Wrap(err)
+	}
+
+	return s.issueInFamily(ctx, familyID, user)
+}
+
+// Rotate exchanges a refresh token for the next token of its family, returning the new token and the user it belongs to
+// ErrRefreshTokenReused is returned, and the family revoked, if the token was already rotated
+func (s *RefreshTokenStore) Rotate(ctx context.Context, token string) (string, *UserInfo, error) {
+	record, err := s.lookup(ctx, token)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if record.Used {
+		if err := s.RevokeFamily(ctx, record.FamilyID); err != nil {
+			return "", nil, ferr.Wrap(err)
+		}
+
+		return "", nil, ErrRefreshTokenReused
+	}
+
+	if _, err := s.family(ctx, record.FamilyID); err != nil {
+		return "", nil, err
+	}
+
+	// the used token is kept until it would have expired, so reuse can still be detected
+	record.Used = true
+
+	err = s.storeJSON(ctx, refreshTokenKey(token), record, time.Until(record.ExpiresAt))
+	if err != nil {
+		return "", nil, ferr.Wrap(err)
+	}
+
+	// using the family extends it, tokens that aren't used for a whole lifetime expire with it
+	err = s.storeJSON(ctx, refreshFamilyKey(record.FamilyID), &refreshTokenFamily{Sub: record.User.Sub}, s.lifetime)
+	if err != nil {
+		return "", nil, ferr.Wrap(err)
+	}
+
+	next, err := s.issueInFamily(ctx, record.FamilyID, record.User)
+	if err != nil {
+		return "", nil, ferr.Wrap(err)
+	}
+
+	return next, record.User, nil
+}
+
+// Revoke revokes the family of a refresh token, as done on logout
+// unknown tokens are ignored
+func (s *RefreshTokenStore) Revoke(ctx context.Context, token string) error {
+	record, err := s.lookup(ctx, token)
+	if errors.Is(err, ErrInvalidRefreshToken) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return s.RevokeFamily(ctx, record.FamilyID)
+}
+
+// RevokeFamily revokes every refresh token of a family
+func (s *RefreshTokenStore) RevokeFamily(ctx context.Context, familyID string) error {
+	err := s.cache.InvalidateValue(ctx, refreshFamilyKey(familyID))
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (s *RefreshTokenStore) issueInFamily(ctx context.Context, familyID string, user *UserInfo) (string, error) {
+	token := randomToken(32)
+
+	record := &refreshTokenRecord{
+		FamilyID:  familyID,
+		User:      user,
+		ExpiresAt: time.Now().Add(s.lifetime),
+	}
+
+	err := s.storeJSON(ctx, refreshTokenKey(token), record, s.lifetime)
+	if err != nil {
+		return "", ferr.Wrap(err)
+	}
+
+	return token, nil
+}
+
+func (s *RefreshTokenStore) lookup(ctx context.Context, token string) (*refreshTokenRecord, error) {
+	if token == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var record refreshTokenRecord
+
+	if err := s.retrieveJSON(ctx, refreshTokenKey(token), &record); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+func (s *RefreshTokenStore) family(ctx context.Context, familyID string) (*refreshTokenFamily, error) {
+	var family refreshTokenFamily
+
+	if err := s.retrieveJSON(ctx, refreshFamilyKey(familyID), &family); err != nil {
+		return nil, err
+	}
+
+	return &family, nil
+}
+
+func (s *RefreshTokenStore) storeJSON(ctx context.Context, key string, value any, expiresIn time.Duration) error {
+	jsonBytes, err := json.Marshal(value)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return s.cache.StoreValueWithExpiry(ctx, key, jsonBytes, expiresIn)
+}
+
+// retrieveJSON returns ErrInvalidRefreshToken if the key is not in the cache
+func (s *RefreshTokenStore) retrieveJSON(ctx context.Context, key string, out any) error {
+	jsonBytes, err := s.cache.RetrieveValue(ctx, key)
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return ErrInvalidRefreshToken
+	} else if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	if err := json.Unmarshal(jsonBytes, out); err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+// refresh tokens are only stored hashed, so a leaked cache doesn't leak usable tokens
+func refreshTokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "refresh:" + hex.EncodeToString(sum[:])
+}
+
+func refreshFamilyKey(familyID string) string {
+	return "refresh-family:" + familyID
+}
+
+// RevocationList tracks revoked JWTs in a cache.Cache, see ValidateJWTOpts
+// entries only live as long as the tokens they revoke could still be valid
+type RevocationList struct {
+	cache            cache.Cache
+	maxTokenLifetime time.Duration
+}
+
+// NewRevocationList creates a RevocationList
+// maxTokenLifetime is the longest lifetime of the tokens checked against it, which is how long RevokeUser is remembered
+func NewRevocationList(c cache.Cache, maxTokenLifetime time.Duration) *RevocationList {
+	if maxTokenLifetime <= 0 {
+		maxTokenLifetime = DefaultInternalTokenLifetime
+	}
+
+	return &RevocationList{
+		cache:            c,
+		maxTokenLifetime: maxTokenLifetime,
+	}
+}
+
+// RevokeToken revokes a single token by its jti, until it expires
+func (rl *RevocationList) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	err := rl.cache.StoreValueWithExpiry(ctx, "revoked-jti:"+jti, []byte("1"), ttl)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+// RevokeUser revokes every token issued to sub before now
+// token issue times only have second precision, so tokens issued within the same second as the revocation stay valid,
+// which keeps tokens issued right after it (ie. by logging in again) from being rejected. Revoke those by jti
+func (rl *RevocationList) RevokeUser(ctx context.Context, sub string) error {
+	revokedAt, err := json.Marshal(time.Now().Unix())
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	err = rl.cache.StoreValueWithExpiry(ctx, "revoked-sub:"+sub, revokedAt, rl.maxTokenLifetime)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+// IsRevoked checks whether a token was revoked by RevokeToken or RevokeUser
+func (rl *RevocationList) IsRevoked(ctx context.Context, claims *jwt.RegisteredClaims) (bool, error) {
+	if claims.ID != "" {
+		_, err := rl.cache.RetrieveValue(ctx, "revoked-jti:"+claims.ID)
+		if err == nil {
+			return true, nil
+		} else if !errors.Is(err, cache.ErrCacheMiss) {
+			return false, ferr.Wrap(err)
+		}
+	}
+
+	revokedAtBytes, err := rl.cache.RetrieveValue(ctx, "revoked-sub:"+claims.Subject)
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return false, nil
+	} else if err != nil {
+		return false, ferr.Wrap(err)
+	}
+
+	var revokedAt int64
+
+	if err := json.Unmarshal(revokedAtBytes, &revokedAt); err != nil {
+		return false, ferr.Wrap(err)
+	}
+
+	// tokens without an issue time can't be proven to be newer than the revocation
+	return claims.IssuedAt == nil || claims.IssuedAt.Unix() < revokedAt, nil
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
+}
+
+// IssueTokenPair mints an internal token and starts a refresh token family for a user
+// opts.Exchange.Refresh must be set
+func (rt *ReTokenizer) IssueTokenPair(ctx context.Context, userInfo *UserInfo, opts *ExchangeOptions) (*ExchangeResponse, error) {
+	refreshToken, err := opts.Refresh.Issue(ctx, userInfo)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	res, err := rt.exchangeResponse(userInfo, opts)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	res.RefreshToken = refreshToken
+
+	return res, nil
+}
+
+// MakeRefreshHandler returns an endpoint that rotates a refresh token, sent as refresh_token in the body,
+// and responds with a new internal token and refresh token
+// opts.Exchange.Refresh must be set
+func (rt *ReTokenizer) MakeRefreshHandler(opts *MiddlewareOptions) func(c *fiber.Ctx) error {
+	if opts.Exchange == nil || opts.Exchange.Refresh == nil {
+		panic("missing exchange refresh token store")
+	}
+
+	opts = rt.withMiddlewareDefaults(opts)
+
+	return func(c *fiber.Ctx) error {
+		spanCtx, span := rt.tracer.Start(c.UserContext(), "retokenizer-refresh")
+		defer span.End()
+
+		var body refreshRequest
+
+		if err := c.BodyParser(&body); err != nil || body.RefreshToken == "" {
+			if opts.LegacyErrorBodies {
+				return c.Status(http.StatusBadRequest).JSON(ErrInvalidRefreshTokenMsg)
+			}
+
+			return ferr.MissingArgument("refresh_token")
+		}
+
+		refreshToken, userInfo, err := opts.Exchange.Refresh.Rotate(spanCtx, body.RefreshToken)
+		if errors.Is(err, ErrRefreshTokenReused) || errors.Is(err, ErrInvalidRefreshToken) {
+			if errors.Is(err, ErrRefreshTokenReused) {
+				opts.Logger.Warn("refresh token reused, revoked its family")
+			}
+
+			if opts.LegacyErrorBodies {
+				return c.Status(http.StatusUnauthorized).JSON(ErrInvalidRefreshTokenMsg)
+			}
+
+			return opts.respondError(c, ferr.InvalidToken)
+		} else if err != nil {
+			opts.Logger.Error("failed to rotate refresh token", zap.Error(err))
+
+			return opts.respondError(c, internalError())
+		}
+
+		res, err := rt.exchangeResponse(userInfo, opts.Exchange)
+		if err != nil {
+			opts.Logger.Error("failed to mint internal token", zap.Error(err))
+
+			return opts.respondError(c, internalError())
+		}
+
+		res.RefreshToken = refreshToken
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		return c.JSON(res)
+	}
+}
+
+// MakeLogoutHandler returns an endpoint that revokes the internal token found by opts.Extractors,
+// and the refresh token family of refresh_token if it is sent in the body
+// opts.Exchange.Revocations must be set
+func (rt *ReTokenizer) MakeLogoutHandler(opts *MiddlewareOptions) func(c *fiber.Ctx) error {
+	if opts.Exchange == nil || opts.Exchange.Revocations == nil {
+		panic("missing exchange revocation list")
+	}
+
+	opts = rt.withMiddlewareDefaults(opts)
+
+	return func(c *fiber.Ctx) error {
+		spanCtx, span := rt.tracer.Start(c.UserContext(), "retokenizer-logout")
+		defer span.End()
+
+		credential, err := extractCredential(c, opts.Extractors)
+		if err != nil {
+			return opts.respondError(c, tokenError(err))
+		}
+
+		token := credential.Token
+
+		if _, err := rt.ValidateInternalToken(token, opts.Exchange.Issuer, opts.Exchange.validateOpts(spanCtx)); err != nil {
+			if isTokenExpired(err) {
+				return opts.respondError(c, ferr.TokenExpired)
+			}
+
+			return opts.respondError(c, ferr.InvalidToken)
+		}
+
+		// the token was just verified
+		var claims jwt.RegisteredClaims
+
+		if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+			return opts.respondError(c, ferr.InvalidToken)
+		}
+
+		err = opts.Exchange.Revocations.RevokeToken(spanCtx, claims.ID, claims.ExpiresAt.Time)
+		if err != nil {
+			opts.Logger.Error("failed to revoke token", zap.Error(err))
+
+			return opts.respondError(c, internalError())
+		}
+
+		// the middleware may have cached the user of the credential, so it must not be served from the cache either
+		if err := opts.InvalidateCachedToken(spanCtx, credential.Scheme, token); err != nil {
+			opts.Logger.Error("failed to invalidate cached token", zap.Error(err))
+
+			return opts.respondError(c, internalError())
+		}
+
+		var body refreshRequest
+
+		if opts.Exchange.Refresh != nil && len(c.Body()) > 0 && c.BodyParser(&body) == nil && body.RefreshToken != "" {
+			if err := opts.Exchange.Refresh.Revoke(spanCtx, body.RefreshToken); err != nil {
+				opts.Logger.Error("failed to revoke refresh token", zap.Error(err))
+
+				return opts.respondError(c, internalError())
+			}
+		}
+
+		return c.SendStatus(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,184 @@
+package retokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokenStore(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	store := NewRefreshTokenStore(cache.NewInMemoryCache(), time.Hour)
+	user := &UserInfo{Sub: "1"}
+
+	first, err := store.Issue(ctx, user)
+	assert.NoError(t, err)
+
+	second, rotatedUser, err := store.Rotate(ctx, first)
+	assert.NoError(t, err)
+	assert.Equal(t, "1", rotatedUser.Sub)
+	assert.NotEqual(t, first, second)
+
+	// reusing the first token revokes the family, including the token that replaced it
+	_, _, err = store.Rotate(ctx, first)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	_, _, err = store.Rotate(ctx, second)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, _, err = store.Rotate(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRevocationList(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	key := []byte("internal-signing-key")
+	rl := NewRevocationList(cache.NewInMemoryCache(), time.Hour)
+	opts := &CreateJWTOpts{Issuer: "fct", ExpiresIn: time.Minute}
+	validateOpts := &ValidateJWTOpts{Revocations: rl}
+
+	token, claims, err := CreateJWTForUser(key, opts, "1", &UserInfo{Sub: "1"})
+	assert.NoError(t, err)
+
+	other, _, err := CreateJWTForUser(key, opts, "1", &UserInfo{Sub: "1"})
+	assert.NoError(t, err)
+
+	_, err = ValidateUserJWT[UserInfo](key, token, "fct", validateOpts)
+	assert.NoError(t, err)
+
+	assert.NoError(t, rl.RevokeToken(ctx, claims.ID, claims.ExpiresAt.Time))
+
+	_, err = ValidateUserJWT[UserInfo](key, token, "fct", validateOpts)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	_, err = ValidateUserJWT[UserInfo](key, other, "fct", validateOpts)
+	assert.NoError(t, err)
+
+	earlierClaims := newUserClaims(opts, "1", &UserInfo{Sub: "1"})
+	earlierClaims.IssuedAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+
+	earlier, err := jwt.NewWithClaims(jwt.SigningMethodHS256, earlierClaims).SignedString(key)
+	assert.NoError(t, err)
+
+	assert.NoError(t, rl.RevokeUser(ctx, "1"))
+
+	_, err = ValidateUserJWT[UserInfo](key, earlier, "fct", validateOpts)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	// iat only has second precision, so tokens from the second of the revocation aren't rejected
+	_, err = ValidateUserJWT[UserInfo](key, other, "fct", validateOpts)
+	assert.NoError(t, err)
+
+	// without a revocation list, revocations are not checked
+	_, err = ValidateUserJWT[UserInfo](key, earlier, "fct")
+	assert.NoError(t, err)
+}
+
+func TestRefreshAndLogoutHandlers(t *testing.T) {
+	t.Parallel()
+
+	rt := New(&Opts{JWTSigningKey: []byte("internal-signing-key")})
+	store := cache.NewInMemoryCache()
+
+	opts := &MiddlewareOptions{
+		AuthProvider: &PlaceholderAuthenticationProvider{},
+		Exchange: &ExchangeOptions{
+			Issuer:      "fct-internal",
+			Refresh:     NewRefreshTokenStore(store, time.Hour),
+			Revocations: NewRevocationList(store, DefaultInternalTokenLifetime),
+		},
+	}
+
+	app := fiber.New()
+	app.Use(ferr.Middleware(false))
+	app.Post("/auth/exchange", rt.MakeExchangeHandler(opts))
+	app.Post("/auth/refresh", rt.MakeRefreshHandler(opts))
+	app.Post("/auth/logout", rt.MakeLogoutHandler(opts))
+	app.Get("/me", rt.MakeFiberMiddleware(opts), func(c *fiber.Ctx) error {
+		return c.SendString(ExtractUserInfo(c.UserContext()).Sub)
+	})
+
+	do := func(target, token, refreshToken string, out any) int {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+
+		if refreshToken != "" {
+			req = httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"refresh_token":"`+refreshToken+`"}`))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		}
+
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if out != nil {
+			assert.NoError(t, json.NewDecoder(res.Body).Decode(out))
+		}
+
+		return res.StatusCode
+	}
+
+	me := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res.StatusCode
+	}
+
+	var exchanged ExchangeResponse
+	assert.Equal(t, http.StatusOK, do("/auth/exchange", "upstream", "", &exchanged))
+	assert.NotEmpty(t, exchanged.RefreshToken)
+
+	var refreshed ExchangeResponse
+	assert.Equal(t, http.StatusOK, do("/auth/refresh", "", exchanged.RefreshToken, &refreshed))
+	assert.NotEmpty(t, refreshed.AccessToken)
+	assert.NotEqual(t, exchanged.RefreshToken, refreshed.RefreshToken)
+	assert.Equal(t, http.StatusOK, me(refreshed.AccessToken))
+
+	// logging out drops anything the middleware cached for the token
+	cacheKey := opts.authCacheKey(&Credential{Scheme: SchemeBearer, Token: refreshed.AccessToken})
+	assert.NoError(t, opts.Cache.StoreValue(context.Background(), cacheKey, []byte(`{"sub":"1"}`)))
+
+	assert.Equal(t, http.StatusNoContent, do("/auth/logout", refreshed.AccessToken, refreshed.RefreshToken, nil))
+
+	_, err := opts.Cache.RetrieveValue(context.Background(), cacheKey)
+	assert.ErrorIs(t, err, cache.ErrCacheMiss)
+
+	assert.Equal(t, http.StatusUnauthorized, me(refreshed.AccessToken))
+	assert.Equal(t, http.StatusOK, me(exchanged.AccessToken))
+	assert.Equal(t, http.StatusUnauthorized, do("/auth/refresh", "", refreshed.RefreshToken, &map[string]any{}))
+
+	// rejected refresh tokens get the same challenge as every other rejected token
+	req := httptest.NewRequest(http.MethodPost, "/auth/refresh", strings.NewReader(`{"refresh_token":"unknown"}`))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	res, err := app.Test(req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.Contains(t, res.Header.Get(fiber.HeaderWWWAuthenticate), "invalid_token")
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("/auth/refresh", "", "", &map[string]any{}))
+}
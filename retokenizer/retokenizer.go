@@ -10,28 +10,47 @@ type Opts struct {
 	Tracer        trace.Tracer
 	Cache         cache.Cache
 	JWTSigningKey []byte
+
+	// KeySet is used instead of JWTSigningKey when set, keys are chosen by the kid header of tokens
+	KeySet *KeySet
 }
 
 type ReTokenizer struct {
 	tracer trace.Tracer
 	cache  cache.Cache
 	key    []byte
+	keySet *KeySet
 }
 
 func New(opts *Opts) *ReTokenizer {
+	tracer := opts.Tracer
+	if tracer == nil {
+		tracer = trace.NewNoopTracerProvider().Tracer("retokenizer")
+	}
+
 	return &ReTokenizer{
-		tracer: opts.Tracer,
+		tracer: tracer,
 		cache:  opts.Cache,
 		key:    opts.JWTSigningKey,
+		keySet: opts.KeySet,
 	}
 }
 
 func (rt *ReTokenizer) KeyFunc() func(token *jwt.Token) (any, error) {
+	if rt.keySet != nil {
+		return rt.keySet.KeyFunc()
+	}
+
 	return func(token *jwt.Token) (any, error) {
 		return rt.key, nil
 	}
 }
 
+// KeySet returns the key set passed in Opts, or nil if the ReTokenizer uses a single signing key
+func (rt *ReTokenizer) KeySet() *KeySet {
+	return rt.keySet
+}
+
 func KeyBasedKeyFunc(key []byte) func(token *jwt.Token) (any, error) {
 	return func(token *jwt.Token) (any, error) {
 		return key, nil
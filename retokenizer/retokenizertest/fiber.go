@@ -0,0 +1,62 @@
+package retokenizertest
+
+import (
+	"encoding/json"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/retokenizer"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// NewRequest builds a request authenticated with token as a bearer token, or an anonymous one if token is empty
+func NewRequest(method, target, token string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+
+	if token != "" {
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+	}
+
+	return req
+}
+
+// EchoUserInfo is a handler responding with the user attached by the middleware, for AssertUserInfo to check
+// ie. app.Get("/me", rt.MakeFiberMiddleware(opts), retokenizertest.EchoUserInfo)
+func EchoUserInfo(c *fiber.Ctx) error {
+	return c.JSON(retokenizer.ExtractUserInfo(c.UserContext()))
+}
+
+// AssertUserInfo sends req to a route handled by EchoUserInfo, and checks it was authenticated as expected
+// a nil expected user checks the request was let through anonymously
+func AssertUserInfo(t testing.TB, app *fiber.App, req *http.Request, expected *retokenizer.UserInfo) bool {
+	t.Helper()
+
+	res, err := app.Test(req)
+	if !assert.NoError(t, err) || !assert.Equal(t, http.StatusOK, res.StatusCode) {
+		return false
+	}
+
+	var actual *retokenizer.UserInfo
+
+	if !assert.NoError(t, json.NewDecoder(res.Body).Decode(&actual)) {
+		return false
+	}
+
+	return assert.Equal(t, expected, actual)
+}
+
+// AssertRejected sends req and checks it was rejected with status, returning the response for further checks
+func AssertRejected(t testing.TB, app *fiber.App, req *http.Request, status int) *http.Response {
+	t.Helper()
+
+	res, err := app.Test(req)
+	if !assert.NoError(t, err) {
+		return nil
+	}
+
+	assert.Equal(t, status, res.StatusCode)
+
+	return res
+}
@@ -0,0 +1,126 @@
+// Package retokenizertest provides utilities for testing code that authenticates with retokenizer
+package retokenizertest
+
+import (
+	"context"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/retokenizer"
+	"sync"
+	"time"
+)
+
+// FakeProvider is a retokenizer.AuthenticationProvider that only accepts the tokens it was given
+// unknown tokens are rejected with retokenizer.ErrInvalidAuthorization, like a real provider would
+type FakeProvider struct {
+	mu      sync.Mutex
+	users   map[string]*retokenizer.UserInfo
+	errs    map[string]error
+	err     error
+	latency time.Duration
+	calls   map[string]int
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		users: make(map[string]*retokenizer.UserInfo),
+		errs:  make(map[string]error),
+		calls: make(map[string]int),
+	}
+}
+
+// AddUser accepts token as the given user
+func (p *FakeProvider) AddUser(token string, user *retokenizer.UserInfo) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.users[token] = user
+
+	return p
+}
+
+// RemoveUser rejects a token that was accepted before, ie. to simulate revocation
+func (p *FakeProvider) RemoveUser(token string) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.users, token)
+
+	return p
+}
+
+// FailToken makes requests with token fail with err
+func (p *FakeProvider) FailToken(token string, err error) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.errs[token] = err
+
+	return p
+}
+
+// FailAll makes every request fail with err, ie. to simulate the provider being down. nil clears it
+func (p *FakeProvider) FailAll(err error) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.err = err
+
+	return p
+}
+
+// SetLatency delays every request, requests whose context ends first fail with ferr.ResourceTimedOut
+func (p *FakeProvider) SetLatency(latency time.Duration) *FakeProvider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latency = latency
+
+	return p
+}
+
+// Calls is how many times the provider was asked about token, ie. to check caching
+func (p *FakeProvider) Calls(token string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.calls[token]
+}
+
+func (p *FakeProvider) GetUserInfo(ctx context.Context, token string) (*retokenizer.UserInfo, error) {
+	p.mu.Lock()
+	p.calls[token]++
+	latency := p.latency
+	p.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return nil, ferr.ResourceTimedOut("fake authentication provider").WithUnderlying(ctx.Err())
+		case <-timer.C:
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	if err, ok := p.errs[token]; ok {
+		return nil, err
+	}
+
+	user, ok := p.users[token]
+	if !ok {
+		return nil, retokenizer.ErrInvalidAuthorization
+	}
+
+	// callers may modify the user, so the stored one is copied
+	userCopy := *user
+
+	return &userCopy, nil
+}
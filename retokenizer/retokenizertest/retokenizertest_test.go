@@ -0,0 +1,67 @@
+package retokenizertest
+
+import (
+	"context"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/retokenizer"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestFakeProviderAndMinter(t *testing.T) {
+	t.Parallel()
+
+	alice := &retokenizer.UserInfo{Sub: "alice", Roles: []string{"admin"}}
+
+	provider := NewFakeProvider().
+		AddUser("alice-token", alice).
+		FailToken("broken-token", errors.New("provider exploded"))
+
+	key := []byte("internal-signing-key")
+	rt := retokenizer.New(&retokenizer.Opts{JWTSigningKey: key})
+	minter := &TokenMinter{Key: key, Issuer: "fct-internal"}
+
+	app := fiber.New()
+	app.Use(ferr.Middleware(false))
+	app.Get("/me", rt.MakeFiberMiddleware(&retokenizer.MiddlewareOptions{
+		AuthProvider: provider,
+		Exchange:     &retokenizer.ExchangeOptions{Issuer: "fct-internal"},
+	}), EchoUserInfo)
+
+	AssertUserInfo(t, app, NewRequest(http.MethodGet, "/me", "alice-token", nil), alice)
+	AssertUserInfo(t, app, NewRequest(http.MethodGet, "/me", minter.Valid(t, alice), nil), alice)
+	assert.Equal(t, 1, provider.Calls("alice-token"))
+
+	AssertRejected(t, app, NewRequest(http.MethodGet, "/me", "", nil), http.StatusUnauthorized)
+	AssertRejected(t, app, NewRequest(http.MethodGet, "/me", "unknown-token", nil), http.StatusUnauthorized)
+	AssertRejected(t, app, NewRequest(http.MethodGet, "/me", "broken-token", nil), http.StatusInternalServerError)
+	AssertRejected(t, app, NewRequest(http.MethodGet, "/me", minter.Tampered(t, alice, "mallory"), nil), http.StatusUnauthorized)
+
+	res := AssertRejected(t, app, NewRequest(http.MethodGet, "/me", minter.Expired(t, alice), nil), http.StatusUnauthorized)
+	assert.Contains(t, res.Header.Get(fiber.HeaderWWWAuthenticate), "expired")
+
+	t.Run("latency", func(t *testing.T) {
+		provider := NewFakeProvider().AddUser("slow-token", alice).SetLatency(time.Second)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := provider.GetUserInfo(ctx, "slow-token")
+		assert.Equal(t, ferr.Code(ferr.CodeTimeout), ferr.Infer(err).Code)
+	})
+
+	t.Run("forced errors", func(t *testing.T) {
+		provider := NewFakeProvider().AddUser("alice-token", alice).FailAll(retokenizer.ErrInvalidAuthorization)
+
+		_, err := provider.GetUserInfo(context.Background(), "alice-token")
+		assert.ErrorIs(t, err, retokenizer.ErrInvalidAuthorization)
+
+		user, err := provider.FailAll(nil).GetUserInfo(context.Background(), "alice-token")
+		assert.NoError(t, err)
+		assert.Equal(t, alice, user)
+	})
+}
@@ -0,0 +1,112 @@
+package retokenizertest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/retokenizer"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TokenMinter mints JWTs the way retokenizer does, for the validation paths to be tested against
+type TokenMinter struct {
+	// Key signs tokens with HS256, KeySet is used instead when it is set
+	Key    []byte
+	KeySet *retokenizer.KeySet
+
+	Issuer   string
+	Audience string
+
+	// ExpiresIn defaults to a minute
+	ExpiresIn time.Duration
+}
+
+// Valid mints a token that validates until it expires
+func (m *TokenMinter) Valid(t testing.TB, user *retokenizer.UserInfo) string {
+	t.Helper()
+
+	expiresIn := m.ExpiresIn
+	if expiresIn <= 0 {
+		expiresIn = time.Minute
+	}
+
+	return m.mint(t, user, expiresIn)
+}
+
+// Expired mints a correctly signed token that expired a minute ago
+func (m *TokenMinter) Expired(t testing.TB, user *retokenizer.UserInfo) string {
+	t.Helper()
+
+	return m.mint(t, user, -time.Minute)
+}
+
+// Tampered mints a valid token, then changes its sub claim to sub without signing it again
+func (m *TokenMinter) Tampered(t testing.TB, user *retokenizer.UserInfo, sub string) string {
+	t.Helper()
+
+	return Tamper(t, m.Valid(t, user), func(claims map[string]any) {
+		claims["sub"] = sub
+
+		if user, ok := claims["user"].(map[string]any); ok {
+			user["sub"] = sub
+		}
+	})
+}
+
+func (m *TokenMinter) mint(t testing.TB, user *retokenizer.UserInfo, expiresIn time.Duration) string {
+	t.Helper()
+
+	opts := &retokenizer.CreateJWTOpts{
+		Audience:  m.Audience,
+		Issuer:    m.Issuer,
+		ExpiresIn: expiresIn,
+	}
+
+	var token string
+	var err error
+
+	if m.KeySet != nil {
+		token, _, err = retokenizer.CreateJWTForUserWithKeySet(m.KeySet, opts, user.Sub, user)
+	} else {
+		token, _, err = retokenizer.CreateJWTForUser(m.Key, opts, user.Sub, user)
+	}
+
+	if err != nil {
+		t.Fatalf("failed to mint token: %v", err)
+	}
+
+	return token
+}
+
+// Tamper changes the claims of a JWT, keeping its original signature
+func Tamper(t testing.TB, token string, modify func(claims map[string]any)) string {
+	t.Helper()
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		t.Fatalf("not a JWT: %s", token)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("failed to decode JWT payload: %v", err)
+	}
+
+	var claims map[string]any
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		t.Fatalf("failed to parse JWT claims: %v", err)
+	}
+
+	modify(claims)
+
+	payload, err = json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to encode JWT claims: %v", err)
+	}
+
+	parts[1] = base64.RawURLEncoding.EncodeToString(payload)
+
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,313 @@
+package retokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultServiceTokenRefreshMargin is how long before expiry cached service tokens are replaced
+var DefaultServiceTokenRefreshMargin = 30 * time.Second
+
+// ServiceToken is a token a service authenticates its own requests with
+type ServiceToken struct {
+	AccessToken string
+	ExpiresAt   time.Time
+}
+
+// TokenSource provides service tokens for outgoing requests, see ServiceTransport and AuthorizeAgent
+type TokenSource interface {
+	ServiceToken(ctx context.Context) (*ServiceToken, error)
+}
+
+// ServiceTokenOpts configures a ServiceTokenSource
+type ServiceTokenOpts struct {
+	// Service is the identity of the calling service, its Sub is the sub of minted tokens
+	Service *UserInfo
+
+	Issuer   string
+	Audience string
+
+	// ExpiresIn defaults to DefaultInternalTokenLifetime
+	ExpiresIn time.Duration
+
+	// RefreshMargin defaults to DefaultServiceTokenRefreshMargin
+	RefreshMargin time.Duration
+}
+
+// ServiceTokenSource mints service tokens with the ReTokenizer's key set or signing key
+// when the context has a user attached by the middleware, it is set as the act claim of the token for auditing
+// tokens are cached per user until they are about to expire
+type ServiceTokenSource struct {
+	rt    *ReTokenizer
+	opts  *ServiceTokenOpts
+	cache *serviceTokenCache
+}
+
+// NewServiceTokenSource creates a ServiceTokenSource, opts.Service is required
+func (rt *ReTokenizer) NewServiceTokenSource(opts *ServiceTokenOpts) *ServiceTokenSource {
+	if opts.Service == nil {
+		panic("missing service identity")
+	}
+
+	if rt.keySet == nil && len(rt.key) == 0 {
+		panic("service tokens require a JWT signing key or key set")
+	}
+
+	return &ServiceTokenSource{
+		rt:    rt,
+		opts:  opts,
+		cache: newServiceTokenCache(opts.RefreshMargin),
+	}
+}
+
+func (s *ServiceTokenSource) ServiceToken(ctx context.Context) (*ServiceToken, error) {
+	var actor *Actor
+
+	if user := ExtractUserInfo(ctx); user != nil {
+		actor = &Actor{Sub: user.Sub, Act: ExtractActor(ctx)}
+	}
+
+	// tokens are cached per actor chain, marshalling an Actor can't fail
+	cacheKey, _ := json.Marshal(actor)
+
+	return s.cache.get(string(cacheKey), func() (*ServiceToken, error) {
+		expiresIn := s.opts.ExpiresIn
+		if expiresIn <= 0 {
+			expiresIn = DefaultInternalTokenLifetime
+		}
+
+		claims := newUserClaims(&CreateJWTOpts{
+			Audience:  s.opts.Audience,
+			Issuer:    s.opts.Issuer,
+			ExpiresIn: expiresIn,
+		}, s.opts.Service.Sub, s.opts.Service)
+
+		claims.Act = actor
+
+		token, err := s.rt.signClaims(claims)
+		if err != nil {
+			return nil, ferr.Wrap(err)
+		}
+
+		return &ServiceToken{AccessToken: token, ExpiresAt: claims.ExpiresAt.Time}, nil
+	})
+}
+
+func (rt *ReTokenizer) signClaims(claims jwt.Claims) (string, error) {
+	if rt.keySet != nil {
+		return rt.keySet.Sign(claims)
+	}
+
+	ss, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(rt.key)
+	if err != nil {
+		return "", ferr.Wrap(err)
+	}
+
+	return ss, nil
+}
+
+// ClientCredentialsTokenSource obtains service tokens from an OAuth token endpoint with the client credentials grant
+// tokens are cached until they are about to expire. The end user can't be added to these tokens, since the
+// token endpoint issues them
+type ClientCredentialsTokenSource struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// Audience is sent as the audience param, which some providers require
+	Audience string
+
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+
+	// RefreshMargin defaults to DefaultServiceTokenRefreshMargin
+	RefreshMargin time.Duration
+
+	once  sync.Once
+	cache *serviceTokenCache
+}
+
+type clientCredentialsResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+func (s *ClientCredentialsTokenSource) ServiceToken(ctx context.Context) (*ServiceToken, error) {
+	s.once.Do(func() {
+		s.cache = newServiceTokenCache(s.RefreshMargin)
+	})
+
+	return s.cache.get("", func() (*ServiceToken, error) {
+		return s.requestToken(ctx)
+	})
+}
+
+func (s *ClientCredentialsTokenSource) requestToken(ctx context.Context) (*ServiceToken, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+
+	if len(s.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.Scopes, " "))
+	}
+
+	if s.Audience != "" {
+		form.Set("audience", s.Audience)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	req.SetBasicAuth(url.QueryEscape(s.ClientID), url.QueryEscape(s.ClientSecret))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+	req.Header.Set(fiber.HeaderAccept, fiber.MIMEApplicationJSON)
+
+	client := s.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ferr.Wrap(fmt.Errorf("token endpoint responded with status %d", res.StatusCode))
+	}
+
+	var body clientCredentialsResponse
+
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	if body.AccessToken == "" {
+		return nil, ferr.Wrap(fmt.Errorf("token endpoint responded without an access token"))
+	}
+
+	expiresIn := time.Duration(body.ExpiresIn) * time.Second
+	if expiresIn <= 0 {
+		expiresIn = DefaultInternalTokenLifetime
+	}
+
+	return &ServiceToken{AccessToken: body.AccessToken, ExpiresAt: time.Now().Add(expiresIn)}, nil
+}
+
+// serviceTokenCache keeps service tokens until they are within the refresh margin of expiring
+type serviceTokenCache struct {
+	mu     sync.Mutex
+	tokens map[string]*ServiceToken
+	margin time.Duration
+}
+
+func newServiceTokenCache(margin time.Duration) *serviceTokenCache {
+	if margin <= 0 {
+		margin = DefaultServiceTokenRefreshMargin
+	}
+
+	return &serviceTokenCache{
+		tokens: make(map[string]*ServiceToken),
+		margin: margin,
+	}
+}
+
+// get returns the cached token for key, or fetches a new one
+// the lock is held while fetching, so concurrent requests don't all fetch their own token
+func (c *serviceTokenCache) get(key string, fetch func() (*ServiceToken, error)) (*ServiceToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+
+	if token, ok := c.tokens[key]; ok && now.Add(c.margin).Before(token.ExpiresAt) {
+		return token, nil
+	}
+
+	token, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	// tokens are cached per user, so expired ones are dropped to keep the map from growing
+	for k, cached := range c.tokens {
+		if now.After(cached.ExpiresAt) {
+			delete(c.tokens, k)
+		}
+	}
+
+	c.tokens[key] = token
+
+	return token, nil
+}
+
+// ServiceTransport is an http.RoundTripper that authenticates requests with a token from Source
+type ServiceTransport struct {
+	Source TokenSource
+
+	// Base makes the actual requests, defaults to http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *ServiceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	token, err := t.Source.ServiceToken(req.Context())
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	// RoundTrippers must not modify the request they are given
+	authorized := req.Clone(req.Context())
+	authorized.Header.Set(fiber.HeaderAuthorization, "Bearer "+token.AccessToken)
+
+	res, err := base.RoundTrip(authorized)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return res, nil
+}
+
+// AuthorizeAgent authenticates a fiber.Agent request with a token from source
+// ie. AuthorizeAgent(c.UserContext(), source, fiber.Get(url))
+func AuthorizeAgent(ctx context.Context, source TokenSource, agent *fiber.Agent) (*fiber.Agent, error) {
+	token, err := source.ServiceToken(ctx)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return agent.Set(fiber.HeaderAuthorization, "Bearer "+token.AccessToken), nil
+}
+
+// ExtractActor returns the act claim of the internal token attached by the middleware in exchange mode
+// the middleware has already verified the token, this only reads it
+func ExtractActor(ctx context.Context) *Actor {
+	token := ExtractInternalToken(ctx)
+	if token == "" {
+		return nil
+	}
+
+	var claims JWTClaims[UserInfo]
+
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return nil
+	}
+
+	return claims.Act
+}
@@ -0,0 +1,124 @@
+package retokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// appTransport sends requests to a fiber app, instead of over the network
+type appTransport struct {
+	app *fiber.App
+}
+
+func (t *appTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.app.Test(req)
+}
+
+func TestServiceTokenSource(t *testing.T) {
+	t.Parallel()
+
+	rt := New(&Opts{JWTSigningKey: []byte("internal-signing-key")})
+
+	source := rt.NewServiceTokenSource(&ServiceTokenOpts{
+		Service: &UserInfo{Sub: "billing", Scopes: []string{"invoices:read"}},
+		Issuer:  "fct-internal",
+	})
+
+	anonymous, err := source.ServiceToken(context.Background())
+	assert.NoError(t, err)
+
+	again, err := source.ServiceToken(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, anonymous.AccessToken, again.AccessToken)
+
+	userCtx := context.WithValue(context.Background(), UserInfoContextKey, &UserInfo{Sub: "alice"})
+
+	onBehalf, err := source.ServiceToken(userCtx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, anonymous.AccessToken, onBehalf.AccessToken)
+
+	// a downstream service in exchange mode accepts the token, and can audit who the call was made for
+	downstream := fiber.New()
+	downstream.Get("/", rt.MakeFiberMiddleware(&MiddlewareOptions{
+		AuthProvider: &rejectingProvider{},
+		Exchange:     &ExchangeOptions{Issuer: "fct-internal"},
+	}), func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"sub":   ExtractUserInfo(c.UserContext()).Sub,
+			"actor": ExtractActor(c.UserContext()),
+		})
+	})
+
+	client := &http.Client{Transport: &ServiceTransport{Source: source, Base: &appTransport{app: downstream}}}
+
+	req, _ := http.NewRequestWithContext(userCtx, http.MethodGet, "http://downstream/", nil)
+
+	res, err := client.Do(req)
+	if assert.NoError(t, err) {
+		var body struct {
+			Sub   string `json:"sub"`
+			Actor *Actor `json:"actor"`
+		}
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		assert.Equal(t, "billing", body.Sub)
+
+		if assert.NotNil(t, body.Actor) {
+			assert.Equal(t, "alice", body.Actor.Sub)
+		}
+	}
+
+	agent, err := AuthorizeAgent(userCtx, source, fiber.Get("http://downstream/"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer "+onBehalf.AccessToken, string(agent.Request().Header.Peek(fiber.HeaderAuthorization)))
+}
+
+func TestClientCredentialsTokenSource(t *testing.T) {
+	t.Parallel()
+
+	var requests int32
+
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "billing" || clientSecret != "secret" || r.FormValue("grant_type") != "client_credentials" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(clientCredentialsResponse{
+			AccessToken: "service-token-" + r.FormValue("scope"),
+			TokenType:   "Bearer",
+			ExpiresIn:   3600,
+		})
+	}))
+	defer tokenServer.Close()
+
+	source := &ClientCredentialsTokenSource{
+		TokenURL:     tokenServer.URL,
+		ClientID:     "billing",
+		ClientSecret: "secret",
+		Scopes:       []string{"invoices:read"},
+	}
+
+	for i := 0; i < 3; i++ {
+		token, err := source.ServiceToken(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, "service-token-invoices:read", token.AccessToken)
+	}
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	badSource := &ClientCredentialsTokenSource{TokenURL: tokenServer.URL, ClientID: "billing"}
+
+	_, err := badSource.ServiceToken(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,493 @@
+package retokenizer
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	lggr "github.com/datomar-labs-inc/FCT_Helpers_Go/logger"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"time"
+)
+
+var (
+	DefaultSessionCookieName  = "session"
+	DefaultSessionIdleTimeout = 30 * time.Minute
+	DefaultSessionMaxLifetime = 12 * time.Hour
+	DefaultCSRFHeader         = "X-CSRF-Token"
+	DefaultCSRFFormField      = "_csrf"
+)
+
+var SessionContextKey = ContextKey("__retokenizer_session_context_key")
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionOptions configures a SessionManager
+type SessionOptions struct {
+	// Cache stores sessions, the session of each cookie is looked up on every request
+	// defaults to a cache.InMemoryCache, which only works with a single instance of the service
+	Cache cache.Cache
+
+	// Secret encrypts and authenticates session cookies, it must be at least 32 bytes
+	Secret []byte
+
+	// CookieName defaults to DefaultSessionCookieName
+	CookieName   string
+	CookieDomain string
+
+	// CookiePath defaults to /
+	CookiePath string
+
+	// CookieSameSite defaults to Lax
+	CookieSameSite string
+
+	// InsecureCookie drops the Secure attribute of the cookie, for local development over http
+	InsecureCookie bool
+
+	// IdleTimeout ends sessions that haven't been used for this long, defaults to DefaultSessionIdleTimeout
+	IdleTimeout time.Duration
+
+	// MaxLifetime ends sessions this long after they were created however much they are used,
+	// defaults to DefaultSessionMaxLifetime
+	MaxLifetime time.Duration
+
+	// IgnoreMissingSessions lets requests without a valid session through the middleware, without a user
+	IgnoreMissingSessions bool
+
+	Logger *lggr.LogWrapper
+}
+
+// Session is a logged in browser session
+type Session struct {
+	ID   string    `json:"id"`
+	User *UserInfo `json:"user"`
+
+	// CSRFToken must be sent with unsafe requests, see SessionManager.CSRFMiddleware
+	CSRFToken string `json:"csrf_token"`
+
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+
+	// ExpiresAt is the absolute end of the session, the idle timeout may end it earlier
+	ExpiresAt time.Time `json:"expires_at"`
+
+	UserAgent string `json:"user_agent"`
+	IP        string `json:"ip"`
+}
+
+// SessionManager provides cookie sessions stored in a cache.Cache, as an alternative to bearer tokens
+// its middleware attaches the session's user for ExtractUserInfo, so handlers work the same with either
+//
+// cookies hold the session ID encrypted with AES-GCM, so they can't be read or forged without the secret.
+// the cache is not expected to provide atomic operations, so the per-user session index may miss sessions
+// created concurrently, which then only end on their own
+type SessionManager struct {
+	opts *SessionOptions
+	aead cipher.AEAD
+}
+
+// NewSessionManager creates a SessionManager, returning an error if the secret is too short
+func NewSessionManager(opts *SessionOptions) (*SessionManager, error) {
+	if len(opts.Secret) < 32 {
+		return nil, ferr.InvalidArgument("session secret", "must be at least 32 bytes")
+	}
+
+	if opts.Cache == nil {
+		opts.Cache = cache.NewInMemoryCache()
+	}
+
+	if opts.CookieName == "" {
+		opts.CookieName = DefaultSessionCookieName
+	}
+
+	if opts.CookiePath == "" {
+		opts.CookiePath = "/"
+	}
+
+	if opts.CookieSameSite == "" {
+		opts.CookieSameSite = fiber.CookieSameSiteLaxMode
+	}
+
+	if opts.IdleTimeout <= 0 {
+		opts.IdleTimeout = DefaultSessionIdleTimeout
+	}
+
+	if opts.MaxLifetime <= 0 {
+		opts.MaxLifetime = DefaultSessionMaxLifetime
+	}
+
+	if opts.Logger == nil {
+		opts.Logger = lggr.GetDetached("retokenizer-sessions")
+	}
+
+	// the secret is not used as the cipher key directly, so it can be shared with other uses
+	mac := hmac.New(sha256.New, opts.Secret)
+	mac.Write([]byte("retokenizer session cookie"))
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return &SessionManager{
+		opts: opts,
+		aead: aead,
+	}, nil
+}
+
+// Create logs a user in, starting a session and setting its cookie
+// any session the request already had is ended, so a session ID planted before login can't be used after it
+func (sm *SessionManager) Create(c *fiber.Ctx, user *UserInfo) (*Session, error) {
+	ctx := c.UserContext()
+
+	if id, ok := sm.sessionID(c); ok {
+		if err := sm.DestroySession(ctx, id); err != nil {
+			return nil, ferr.Wrap(err)
+		}
+	}
+
+	now := time.Now()
+
+	session := &Session{
+		ID:         randomToken(32),
+		User:       user,
+		CSRFToken:  randomToken(32),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(sm.opts.MaxLifetime),
+		UserAgent:  c.Get(fiber.HeaderUserAgent),
+		IP:         c.IP(),
+	}
+
+	if err := sm.store(ctx, session); err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	if err := sm.addToUserIndex(ctx, user.Sub, session.ID); err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	cookie, err := sm.encryptID(session.ID)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     sm.opts.CookieName,
+		Value:    cookie,
+		Path:     sm.opts.CookiePath,
+		Domain:   sm.opts.CookieDomain,
+		Expires:  session.ExpiresAt,
+		Secure:   !sm.opts.InsecureCookie,
+		HTTPOnly: true,
+		SameSite: sm.opts.CookieSameSite,
+	})
+
+	return session, nil
+}
+
+// Destroy logs the request's session out, and clears its cookie
+func (sm *SessionManager) Destroy(c *fiber.Ctx) error {
+	if id, ok := sm.sessionID(c); ok {
+		if err := sm.DestroySession(c.UserContext(), id); err != nil {
+			return ferr.Wrap(err)
+		}
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     sm.opts.CookieName,
+		Path:     sm.opts.CookiePath,
+		Domain:   sm.opts.CookieDomain,
+		Expires:  time.Unix(0, 0),
+		Secure:   !sm.opts.InsecureCookie,
+		HTTPOnly: true,
+		SameSite: sm.opts.CookieSameSite,
+	})
+
+	return nil
+}
+
+// Get loads a session, returning ErrSessionNotFound if it doesn't exist or has ended
+func (sm *SessionManager) Get(ctx context.Context, id string) (*Session, error) {
+	jsonBytes, err := sm.opts.Cache.RetrieveValue(ctx, sessionKey(id))
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return nil, ErrSessionNotFound
+	} else if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	var session Session
+
+	if err := json.Unmarshal(jsonBytes, &session); err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return nil, ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+// DestroySession ends a session by its ID, ie. to log out a single device from a list of sessions
+func (sm *SessionManager) DestroySession(ctx context.Context, id string) error {
+	err := sm.opts.Cache.InvalidateValue(ctx, sessionKey(id))
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+// ListUserSessions returns the active sessions of a user
+func (sm *SessionManager) ListUserSessions(ctx context.Context, sub string) ([]*Session, error) {
+	ids, err := sm.userIndex(ctx, sub)
+	if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	var sessions []*Session
+
+	for _, id := range ids {
+		session, err := sm.Get(ctx, id)
+		if errors.Is(err, ErrSessionNotFound) {
+			continue
+		} else if err != nil {
+			return nil, ferr.Wrap(err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// DestroyUserSessions ends every session of a user, logging them out everywhere
+func (sm *SessionManager) DestroyUserSessions(ctx context.Context, sub string) error {
+	ids, err := sm.userIndex(ctx, sub)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	for _, id := range ids {
+		if err := sm.DestroySession(ctx, id); err != nil {
+			return ferr.Wrap(err)
+		}
+	}
+
+	err = sm.opts.Cache.InvalidateValue(ctx, sessionUserKey(sub))
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+// Middleware authenticates requests by their session cookie, attaching the user for ExtractUserInfo
+// and the session for ExtractSession. Each request extends the session until its idle timeout
+// requests without a valid session fail with ferr.Unauthenticated, unless IgnoreMissingSessions is set
+func (sm *SessionManager) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+
+		session, err := sm.sessionFromRequest(c)
+		if errors.Is(err, ErrSessionNotFound) {
+			if sm.opts.IgnoreMissingSessions {
+				return c.Next()
+			}
+
+			return ferr.Unauthenticated
+		} else if err != nil {
+			sm.opts.Logger.Error("failed to load session", zap.Error(err))
+
+			return internalError()
+		}
+
+		// sessions are only written back occasionally, instead of on every request
+		if time.Since(session.LastSeenAt) > sm.opts.IdleTimeout/10 {
+			session.LastSeenAt = time.Now()
+
+			if err := sm.store(ctx, session); err != nil {
+				sm.opts.Logger.Error("failed to extend session", zap.Error(err))
+			}
+		}
+
+		ctx = context.WithValue(ctx, SessionContextKey, session)
+		c.SetUserContext(context.WithValue(ctx, UserInfoContextKey, session.User))
+
+		return c.Next()
+	}
+}
+
+// CSRFMiddleware rejects unsafe requests that don't carry the session's CSRF token, in the DefaultCSRFHeader header
+// or the DefaultCSRFFormField form field. It must run after Middleware, requests without a session are let through
+// since cookies can't authenticate them. Fails with ferr.InvalidCSRFToken
+func (sm *SessionManager) CSRFMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		switch c.Method() {
+		case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+			return c.Next()
+		}
+
+		session := ExtractSession(c.UserContext())
+		if session == nil {
+			return c.Next()
+		}
+
+		token := c.Get(DefaultCSRFHeader)
+		if token == "" {
+			token = c.FormValue(DefaultCSRFFormField)
+		}
+
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(session.CSRFToken)) != 1 {
+			return ferr.InvalidCSRFToken
+		}
+
+		return c.Next()
+	}
+}
+
+// ExtractSession will extract the session attached by SessionManager.Middleware
+// meant to be called with fiber's UserContext
+// ie. ExtractSession(c.UserContext())
+func ExtractSession(ctx context.Context) *Session {
+	if v, ok := ctx.Value(SessionContextKey).(*Session); ok {
+		return v
+	}
+
+	return nil
+}
+
+func (sm *SessionManager) sessionFromRequest(c *fiber.Ctx) (*Session, error) {
+	id, ok := sm.sessionID(c)
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	return sm.Get(c.UserContext(), id)
+}
+
+// sessionID decrypts the session cookie, returning false if there isn't a valid one
+func (sm *SessionManager) sessionID(c *fiber.Ctx) (string, bool) {
+	cookie := c.Cookies(sm.opts.CookieName)
+	if cookie == "" {
+		return "", false
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(cookie)
+	if err != nil || len(sealed) < sm.aead.NonceSize() {
+		return "", false
+	}
+
+	nonce, ciphertext := sealed[:sm.aead.NonceSize()], sealed[sm.aead.NonceSize():]
+
+	id, err := sm.aead.Open(nil, nonce, ciphertext, []byte(sm.opts.CookieName))
+	if err != nil {
+		return "", false
+	}
+
+	return string(id), true
+}
+
+func (sm *SessionManager) encryptID(id string) (string, error) {
+	nonce := make([]byte, sm.aead.NonceSize())
+
+	if _, err := rand.Read(nonce); err != nil {
+		return "", ferr.Wrap(err)
+	}
+
+	// the cookie name is authenticated too, so a cookie can't be moved to another cookie using the same secret
+	sealed := sm.aead.Seal(nonce, nonce, []byte(id), []byte(sm.opts.CookieName))
+
+	return base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// store saves a session until its idle timeout, or its absolute expiry if that comes first
+func (sm *SessionManager) store(ctx context.Context, session *Session) error {
+	ttl := sm.opts.IdleTimeout
+	if untilExpiry := time.Until(session.ExpiresAt); untilExpiry < ttl {
+		ttl = untilExpiry
+	}
+
+	jsonBytes, err := json.Marshal(session)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	err = sm.opts.Cache.StoreValueWithExpiry(ctx, sessionKey(session.ID), jsonBytes, ttl)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+func (sm *SessionManager) userIndex(ctx context.Context, sub string) ([]string, error) {
+	jsonBytes, err := sm.opts.Cache.RetrieveValue(ctx, sessionUserKey(sub))
+	if errors.Is(err, cache.ErrCacheMiss) {
+		return nil, nil
+	} else if err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	var ids []string
+
+	if err := json.Unmarshal(jsonBytes, &ids); err != nil {
+		return nil, ferr.Wrap(err)
+	}
+
+	return ids, nil
+}
+
+// addToUserIndex adds a session to the user's index, dropping sessions that have ended
+func (sm *SessionManager) addToUserIndex(ctx context.Context, sub, id string) error {
+	ids, err := sm.userIndex(ctx, sub)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	active := []string{id}
+
+	for _, existing := range ids {
+		if _, err := sm.Get(ctx, existing); err == nil {
+			active = append(active, existing)
+		} else if !errors.Is(err, ErrSessionNotFound) {
+			return ferr.Wrap(err)
+		}
+	}
+
+	jsonBytes, err := json.Marshal(active)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	// no session outlives its max lifetime, so neither does the index after its newest session
+	err = sm.opts.Cache.StoreValueWithExpiry(ctx, sessionUserKey(sub), jsonBytes, sm.opts.MaxLifetime)
+	if err != nil {
+		return ferr.Wrap(err)
+	}
+
+	return nil
+}
+
+func sessionKey(id string) string {
+	return "session:" + id
+}
+
+func sessionUserKey(sub string) string {
+	return "session-user:" + sub
+}
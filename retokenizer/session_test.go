@@ -0,0 +1,184 @@
+package retokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/cache"
+	"github.com/datomar-labs-inc/FCT_Helpers_Go/ferr"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	_, err := NewSessionManager(&SessionOptions{Secret: []byte("short")})
+	assert.Error(t, err)
+
+	newApp := func(maxLifetime time.Duration) (*fiber.App, *SessionManager) {
+		sm, err := NewSessionManager(&SessionOptions{
+			Cache:       cache.NewInMemoryCache(),
+			Secret:      []byte("a-session-secret-of-at-least-32-bytes"),
+			MaxLifetime: maxLifetime,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		app := fiber.New()
+		app.Use(ferr.Middleware(false))
+
+		app.Post("/login/:sub", func(c *fiber.Ctx) error {
+			session, err := sm.Create(c, &UserInfo{Sub: c.Params("sub")})
+			if err != nil {
+				return err
+			}
+
+			return c.JSON(fiber.Map{"csrf_token": session.CSRFToken})
+		})
+
+		authenticated := app.Group("", sm.Middleware(), sm.CSRFMiddleware())
+
+		authenticated.Get("/me", func(c *fiber.Ctx) error {
+			return c.SendString(ExtractUserInfo(c.UserContext()).Sub)
+		})
+
+		authenticated.Post("/logout", func(c *fiber.Ctx) error {
+			return sm.Destroy(c)
+		})
+
+		return app, sm
+	}
+
+	type login struct {
+		cookie *http.Cookie
+		csrf   string
+	}
+
+	doLogin := func(app *fiber.App, sub string) login {
+		res, err := app.Test(httptest.NewRequest(http.MethodPost, "/login/"+sub, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var body map[string]string
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+
+		cookies := res.Cookies()
+		if !assert.Len(t, cookies, 1) {
+			t.FailNow()
+		}
+
+		assert.True(t, cookies[0].HttpOnly)
+		assert.True(t, cookies[0].Secure)
+
+		return login{cookie: cookies[0], csrf: body["csrf_token"]}
+	}
+
+	do := func(app *fiber.App, method, target string, cookie *http.Cookie, csrf string) int {
+		req := httptest.NewRequest(method, target, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+
+		if csrf != "" {
+			req.Header.Set(DefaultCSRFHeader, csrf)
+		}
+
+		res, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return res.StatusCode
+	}
+
+	app, sm := newApp(time.Hour)
+
+	laptop := doLogin(app, "alice")
+	phone := doLogin(app, "alice")
+
+	assert.Equal(t, http.StatusOK, do(app, http.MethodGet, "/me", laptop.cookie, ""))
+	assert.Equal(t, http.StatusUnauthorized, do(app, http.MethodGet, "/me", nil, ""))
+
+	tampered := *laptop.cookie
+	tampered.Value = tampered.Value[:len(tampered.Value)-2] + "AA"
+	assert.Equal(t, http.StatusUnauthorized, do(app, http.MethodGet, "/me", &tampered, ""))
+
+	sessions, err := sm.ListUserSessions(context.Background(), "alice")
+	assert.NoError(t, err)
+	assert.Len(t, sessions, 2)
+
+	// unsafe requests need the session's csrf token
+	assert.Equal(t, http.StatusForbidden, do(app, http.MethodPost, "/logout", laptop.cookie, ""))
+	assert.Equal(t, http.StatusForbidden, do(app, http.MethodPost, "/logout", laptop.cookie, phone.csrf))
+	assert.Equal(t, http.StatusOK, do(app, http.MethodPost, "/logout", laptop.cookie, laptop.csrf))
+
+	assert.Equal(t, http.StatusUnauthorized, do(app, http.MethodGet, "/me", laptop.cookie, ""))
+	assert.Equal(t, http.StatusOK, do(app, http.MethodGet, "/me", phone.cookie, ""))
+
+	assert.NoError(t, sm.DestroyUserSessions(context.Background(), "alice"))
+	assert.Equal(t, http.StatusUnauthorized, do(app, http.MethodGet, "/me", phone.cookie, ""))
+
+	t.Run("absolute lifetime", func(t *testing.T) {
+		app, _ := newApp(50 * time.Millisecond)
+
+		session := doLogin(app, "bob")
+		assert.Equal(t, http.StatusOK, do(app, http.MethodGet, "/me", session.cookie, ""))
+
+		time.Sleep(100 * time.Millisecond)
+
+		assert.Equal(t, http.StatusUnauthorized, do(app, http.MethodGet, "/me", session.cookie, ""))
+	})
+}
+
+func TestSessionsConcurrently(t *testing.T) {
+	t.Parallel()
+
+	// without a Cache, sessions are kept in the default in memory cache
+	sm, err := NewSessionManager(&SessionOptions{Secret: []byte("a-session-secret-of-at-least-32-bytes")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app := fiber.New()
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+
+		go func(sub string) {
+			defer wg.Done()
+
+			c := app.AcquireCtx(&fasthttp.RequestCtx{})
+			defer app.ReleaseCtx(c)
+
+			for j := 0; j < 5; j++ {
+				session, err := sm.Create(c, &UserInfo{Sub: sub})
+				if !assert.NoError(t, err) {
+					return
+				}
+
+				_, err = sm.Get(ctx, session.ID)
+				assert.NoError(t, err)
+
+				sessions, err := sm.ListUserSessions(ctx, sub)
+				assert.NoError(t, err)
+				assert.Len(t, sessions, j+1)
+			}
+
+			assert.NoError(t, sm.DestroyUserSessions(ctx, sub))
+		}(strconv.Itoa(i))
+	}
+
+	wg.Wait()
+}
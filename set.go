@@ -0,0 +1,129 @@
+package fcthelp
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// Set is an unordered collection of unique items
+type Set[T comparable] map[T]struct{}
+
+func NewSet[T comparable](items ...T) Set[T] {
+	s := make(Set[T], len(items))
+	s.Add(items...)
+
+	return s
+}
+
+func (s Set[T]) Add(items ...T) {
+	for _, item := range items {
+		s[item] = struct{}{}
+	}
+}
+
+func (s Set[T]) Remove(items ...T) {
+	for _, item := range items {
+		delete(s, item)
+	}
+}
+
+func (s Set[T]) Contains(item T) bool {
+	_, ok := s[item]
+	return ok
+}
+
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+// Items returns the items of the set in no particular order
+func (s Set[T]) Items() []T {
+	items := make([]T, 0, len(s))
+	for item := range s {
+		items = append(items, item)
+	}
+
+	return items
+}
+
+// Union returns a new set with every item that is in s or other
+func (s Set[T]) Union(other Set[T]) Set[T] {
+	result := make(Set[T], len(s)+len(other))
+
+	for item := range s {
+		result.Add(item)
+	}
+
+	for item := range other {
+		result.Add(item)
+	}
+
+	return result
+}
+
+// Intersection returns a new set with the items that are in both s and other
+func (s Set[T]) Intersection(other Set[T]) Set[T] {
+	small, large := s, other
+	if len(small) > len(large) {
+		small, large = large, small
+	}
+
+	result := make(Set[T])
+
+	for item := range small {
+		if large.Contains(item) {
+			result.Add(item)
+		}
+	}
+
+	return result
+}
+
+// Difference returns a new set with the items of s that are not in other
+func (s Set[T]) Difference(other Set[T]) Set[T] {
+	result := make(Set[T])
+
+	for item := range s {
+		if !other.Contains(item) {
+			result.Add(item)
+		}
+	}
+
+	return result
+}
+
+// MarshalJSON writes the set as a json array
+// items are sorted by their json encoding so that the output is deterministic
+func (s Set[T]) MarshalJSON() ([]byte, error) {
+	encoded := make([][]byte, 0, len(s))
+
+	for item := range s {
+		itemJSON, err := json.Marshal(item)
+		if err != nil {
+			return nil, err
+		}
+
+		encoded = append(encoded, itemJSON)
+	}
+
+	sort.Slice(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	})
+
+	return append(append([]byte("["), bytes.Join(encoded, []byte(","))...), ']'), nil
+}
+
+// UnmarshalJSON reads a json array into the set, duplicate items are ignored
+func (s *Set[T]) UnmarshalJSON(data []byte) error {
+	var items []T
+
+	err := json.Unmarshal(data, &items)
+	if err != nil {
+		return err
+	}
+
+	*s = NewSet(items...)
+
+	return nil
+}
@@ -0,0 +1,95 @@
+package fcthelp
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"sort"
+	"testing"
+)
+
+func sortedItems(s Set[int]) []int {
+	items := s.Items()
+	sort.Ints(items)
+
+	return items
+}
+
+func TestSet(t *testing.T) {
+	t.Parallel()
+
+	s := NewSet(1, 2, 2, 3)
+	assert.Equal(t, 3, s.Len())
+	assert.True(t, s.Contains(2))
+	assert.False(t, s.Contains(4))
+
+	s.Add(4, 1)
+	s.Remove(2, 5)
+	assert.Equal(t, []int{1, 3, 4}, sortedItems(s))
+
+	empty := NewSet[int]()
+	assert.Equal(t, 0, empty.Len())
+	assert.Empty(t, empty.Items())
+
+	var nilSet Set[int]
+	assert.False(t, nilSet.Contains(1))
+	assert.Equal(t, 0, nilSet.Len())
+	assert.Empty(t, nilSet.Items())
+	assert.NotPanics(t, func() { nilSet.Remove(1) })
+}
+
+func TestSetOperations(t *testing.T) {
+	t.Parallel()
+
+	a := NewSet(1, 2, 3)
+	b := NewSet(2, 3, 4)
+
+	var nilSet Set[int]
+
+	assert.Equal(t, []int{1, 2, 3, 4}, sortedItems(a.Union(b)))
+	assert.Equal(t, []int{2, 3}, sortedItems(a.Intersection(b)))
+	assert.Equal(t, []int{2, 3}, sortedItems(b.Intersection(a)))
+	assert.Equal(t, []int{1}, sortedItems(a.Difference(b)))
+	assert.Equal(t, []int{4}, sortedItems(b.Difference(a)))
+
+	// operations return new sets
+	assert.Equal(t, []int{1, 2, 3}, sortedItems(a))
+
+	assert.Equal(t, []int{1, 2, 3}, sortedItems(a.Union(nilSet)))
+	assert.Empty(t, sortedItems(a.Intersection(nilSet)))
+	assert.Equal(t, []int{1, 2, 3}, sortedItems(a.Difference(nilSet)))
+	assert.Empty(t, sortedItems(nilSet.Union(nilSet)))
+	assert.Empty(t, sortedItems(nilSet.Difference(a)))
+
+	// the results of operations on nil sets can be added to
+	union := nilSet.Union(nilSet)
+	union.Add(1)
+	assert.True(t, union.Contains(1))
+}
+
+func TestSetJSON(t *testing.T) {
+	t.Parallel()
+
+	out, err := json.Marshal(NewSet("b", "c", "a"))
+	assert.NoError(t, err)
+	assert.Equal(t, `["a","b","c"]`, string(out))
+
+	out, err = json.Marshal(NewSet[string]())
+	assert.NoError(t, err)
+	assert.Equal(t, `[]`, string(out))
+
+	var s Set[int]
+	assert.NoError(t, json.Unmarshal([]byte(`[3, 1, 3]`), &s))
+	assert.Equal(t, []int{1, 3}, sortedItems(s))
+
+	assert.NoError(t, json.Unmarshal([]byte(`null`), &s))
+	assert.Equal(t, 0, s.Len())
+
+	assert.Error(t, json.Unmarshal([]byte(`["a"]`), &s))
+
+	var doc struct {
+		Tags Set[string] `json:"tags"`
+	}
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"tags": ["x", "y"]}`), &doc))
+	assert.True(t, doc.Tags.Contains("y"))
+}